@@ -0,0 +1,221 @@
+package budget
+
+import (
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/circuitbreaker/internal/fsm"
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/window"
+)
+
+// Option is error budget breaker option function.
+type Option func(*options)
+
+var (
+	_ circuitbreaker.StateCircuitBreaker = (*Breaker)(nil)
+)
+
+// options is a breaker options.
+type options struct {
+	name          string
+	onStateChange circuitbreaker.StateChangeFunc
+	clock         clock.Clock
+
+	objective float64
+	burnRate  float64
+	request   int64
+	bucket    int
+	window    time.Duration
+	openWait  time.Duration
+}
+
+// WithClock with the time source of the breaker, default is the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithName with the name of the breaker passed to the state change callback.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// OnStateChange with the callback called on every state transition,
+// it must not call back into the breaker.
+func OnStateChange(f circuitbreaker.StateChangeFunc) Option {
+	return func(o *options) {
+		o.onStateChange = f
+	}
+}
+
+// WithObjective with the SLO success ratio in range (0, 1), default is 0.999.
+// The error budget is 1 - objective.
+func WithObjective(o float64) Option {
+	return func(c *options) {
+		c.objective = o
+	}
+}
+
+// WithBurnRate with the burn rate threshold, default is 14.4.
+// The burn rate is how fast the error budget is consumed relative to the SLO,
+// a burn rate of 1 consumes exactly the whole budget within the SLO period.
+func WithBurnRate(r float64) Option {
+	return func(o *options) {
+		o.burnRate = r
+	}
+}
+
+// WithRequest with the minimum number of requests required before the
+// burn rate can be calculated, default is 5.
+func WithRequest(r int64) Option {
+	return func(o *options) {
+		o.request = r
+	}
+}
+
+// WithWindow with the duration size of the statistical window, default is 1 hour.
+func WithWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.window = d
+	}
+}
+
+// WithBucket set the bucket number in a window duration.
+func WithBucket(b int) Option {
+	return func(o *options) {
+		o.bucket = b
+	}
+}
+
+// WithOpenWait with the duration the breaker stays open before
+// it transitions to half-open.
+func WithOpenWait(d time.Duration) Option {
+	return func(o *options) {
+		o.openWait = d
+	}
+}
+
+// Breaker is a CircuitBreaker pattern which trips when the SLO error budget
+// burns faster than the configured rate.
+//
+// closed: requests are allowed, the breaker opens once the error ratio within
+// the window divided by the error budget reaches the burn rate threshold.
+// open: requests are rejected, after the open wait duration the state
+// transitions to half-open.
+// half-open: one probe request is allowed at a time, the breaker closes
+// on a successful probe, and opens again on a failed probe.
+//
+// See https://sre.google/workbook/alerting-on-slos/ for the burn rate.
+type Breaker struct {
+	*fsm.Machine
+	trip *trip
+}
+
+// trip is the trip condition of the breaker.
+type trip struct {
+	stat window.RollingCounter
+	opts options
+}
+
+// NewBreaker return an error budget breaker with options.
+func NewBreaker(opts ...Option) *Breaker {
+	opt := options{
+		objective: 0.999,
+		burnRate:  14.4,
+		request:   5,
+		bucket:    60,
+		window:    time.Hour,
+		openWait:  10 * time.Second,
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	opt.clock = clock.OrNew(opt.clock)
+	t := &trip{opts: opt}
+	return &Breaker{
+		Machine: fsm.New(t, fsm.Options{
+			Name:          opt.name,
+			OnStateChange: opt.onStateChange,
+			Clock:         opt.clock,
+			OpenWait:      opt.openWait,
+		}),
+		trip: t,
+	}
+}
+
+// BurnRate returns the current burn rate of the error budget within the window.
+func (b *Breaker) BurnRate() (rate float64) {
+	b.Locked(func() {
+		rate = b.trip.burnRate(b.trip.summary())
+	})
+	return
+}
+
+// MarkSuccess mark request is success.
+func (b *Breaker) MarkSuccess() {
+	b.Mark(false, false)
+}
+
+// MarkFailed mark request is failed.
+func (b *Breaker) MarkFailed() {
+	b.Mark(true, false)
+}
+
+func (t *trip) Reset(state circuitbreaker.State) {
+	if state != circuitbreaker.StateClosed {
+		return
+	}
+	t.stat = window.NewRollingCounter(window.RollingCounterOpts{
+		Size:           t.opts.bucket,
+		BucketDuration: time.Duration(int64(t.opts.window) / int64(t.opts.bucket)),
+		Clock:          t.opts.clock,
+	})
+}
+
+func (t *trip) Mark(state circuitbreaker.State, failed, _ bool) circuitbreaker.State {
+	switch state {
+	case circuitbreaker.StateClosed:
+		if !failed {
+			t.stat.Add(0)
+			return state
+		}
+		t.stat.Add(1)
+		failed, total := t.summary()
+		if total >= t.opts.request && t.burnRate(failed, total) >= t.opts.burnRate {
+			return circuitbreaker.StateOpen
+		}
+		return state
+	case circuitbreaker.StateHalfOpen:
+		if failed {
+			return circuitbreaker.StateOpen
+		}
+		return circuitbreaker.StateClosed
+	}
+	return state
+}
+
+// summary returns the failed and total requests within the window.
+func (t *trip) summary() (failed, total int64) {
+	t.stat.Reduce(func(iterator window.Iterator) float64 {
+		for iterator.Next() {
+			bucket := iterator.Bucket()
+			total += bucket.Count
+			for _, p := range bucket.Points {
+				failed += int64(p)
+			}
+		}
+		return 0
+	})
+	return
+}
+
+func (t *trip) burnRate(failed, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(failed) / float64(total) / (1 - t.opts.objective)
+}
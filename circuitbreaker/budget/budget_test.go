@@ -0,0 +1,81 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func getBudgetBreaker(opts ...Option) *Breaker {
+	return NewBreaker(append([]Option{
+		WithObjective(0.99),
+		WithBurnRate(10),
+		WithRequest(10),
+		WithWindow(time.Second),
+		WithBucket(10),
+		WithOpenWait(100 * time.Millisecond),
+	}, opts...)...)
+}
+
+func TestBudgetClose(t *testing.T) {
+	b := getBudgetBreaker()
+	// error ratio 9% burns the 1% budget at 9x
+	for i := 0; i < 91; i++ {
+		b.MarkSuccess()
+	}
+	for i := 0; i < 9; i++ {
+		b.MarkFailed()
+	}
+	assert.InDelta(t, 9, b.BurnRate(), 1e-9)
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	assert.Nil(t, b.Allow())
+
+	// minimum requests
+	b = getBudgetBreaker()
+	for i := 0; i < 9; i++ {
+		b.MarkFailed()
+	}
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+}
+
+func TestBudgetOpen(t *testing.T) {
+	b := getBudgetBreaker()
+	for i := 0; i < 90; i++ {
+		b.MarkSuccess()
+	}
+	for i := 0; i < 9; i++ {
+		b.MarkFailed()
+	}
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	b.MarkFailed()
+	b.MarkFailed()
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+}
+
+func TestBudgetHalfOpen(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	b := getBudgetBreaker(WithClock(c))
+	for i := 0; i < 10; i++ {
+		b.MarkFailed()
+	}
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+	c.Advance(99 * time.Millisecond)
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+	c.Advance(time.Millisecond)
+	assert.Nil(t, b.Allow())
+	assert.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+	b.MarkFailed()
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+
+	c.Advance(100 * time.Millisecond)
+	assert.Nil(t, b.Allow())
+	b.MarkSuccess()
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	// statistics are reset after closed
+	assert.Equal(t, float64(0), b.BurnRate())
+}
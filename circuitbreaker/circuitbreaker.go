@@ -2,14 +2,99 @@ package circuitbreaker
 
 import (
 	"errors"
+	"time"
 )
 
 // ErrNotAllowed error not allowed.
 var ErrNotAllowed = errors.New("circuitbreaker: not allowed for circuit open")
 
+// State is the state of a circuit breaker.
+type State int32
+
+const (
+	// StateOpen circuit breaker is open, requests are rejected.
+	StateOpen State = iota
+	// StateClosed circuit breaker is closed, requests are allowed.
+	StateClosed
+	// StateHalfOpen circuit breaker is probing the backend with a
+	// limited number of requests.
+	StateHalfOpen
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// StateChangeFunc is called once the state of the named circuit breaker
+// transitions from one state to another.
+type StateChangeFunc func(name string, from, to State)
+
 // CircuitBreaker is a circuit breaker.
 type CircuitBreaker interface {
 	Allow() error
 	MarkSuccess()
 	MarkFailed()
 }
+
+// StateCircuitBreaker is a circuit breaker which exposes its current state.
+type StateCircuitBreaker interface {
+	CircuitBreaker
+	// State returns the current state of the breaker.
+	State() State
+}
+
+// Wrapper is a circuit breaker wrapping another one, e.g. latency.Breaker.
+type Wrapper interface {
+	CircuitBreaker
+	// Unwrap returns the wrapped circuit breaker.
+	Unwrap() CircuitBreaker
+}
+
+// LatencyCircuitBreaker is a circuit breaker which also learns from
+// the latency of requests.
+type LatencyCircuitBreaker interface {
+	CircuitBreaker
+	// MarkDone marks the request is done with its latency,
+	// the request is failed if err is not nil.
+	MarkDone(latency time.Duration, err error)
+}
+
+// RejectionMarker is a circuit breaker which counts the requests rejected
+// by itself, e.g. the sre breaker raises the drop ratio by them.
+type RejectionMarker interface {
+	CircuitBreaker
+	// MarkRejected marks the request is rejected by Allow.
+	MarkRejected()
+}
+
+// MarkDone marks the request allowed by cb is done, the request is failed
+// if err is not nil. The latency is also marked if cb is a LatencyCircuitBreaker.
+func MarkDone(cb CircuitBreaker, latency time.Duration, err error) {
+	if lcb, ok := cb.(LatencyCircuitBreaker); ok {
+		lcb.MarkDone(latency, err)
+		return
+	}
+	if err != nil {
+		cb.MarkFailed()
+		return
+	}
+	cb.MarkSuccess()
+}
+
+// MarkRejected marks the request is rejected by cb if cb is a RejectionMarker.
+// It should be called once Allow returns an error, and it is a no-op
+// for the breakers which do not count the rejected requests.
+func MarkRejected(cb CircuitBreaker) {
+	if rm, ok := cb.(RejectionMarker); ok {
+		rm.MarkRejected()
+	}
+}
@@ -0,0 +1,269 @@
+package classic
+
+import (
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/circuitbreaker/internal/fsm"
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/window"
+)
+
+// Option is classic breaker option function.
+type Option func(*options)
+
+var (
+	_ circuitbreaker.StateCircuitBreaker   = (*Breaker)(nil)
+	_ circuitbreaker.LatencyCircuitBreaker = (*Breaker)(nil)
+)
+
+// options is a breaker options.
+type options struct {
+	clock clock.Clock
+
+	name             string
+	onStateChange    circuitbreaker.StateChangeFunc
+	failureRate      float64
+	slowCallRate     float64
+	slowCallDuration time.Duration
+	request          int64
+	bucket           int
+	window           time.Duration
+	openWait         time.Duration
+	halfOpenCalls    int64
+}
+
+// WithClock with the time source of the breaker, default is the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithName with the name of the breaker passed to the state change callback.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// OnStateChange with the callback called on every state transition,
+// it must not call back into the breaker.
+func OnStateChange(f circuitbreaker.StateChangeFunc) Option {
+	return func(o *options) {
+		o.onStateChange = f
+	}
+}
+
+// WithFailureRate with the failure rate threshold in range (0, 1], default is 0.5.
+// When the failure rate is equal or greater than the threshold, the breaker
+// transitions to open.
+func WithFailureRate(r float64) Option {
+	return func(o *options) {
+		o.failureRate = r
+	}
+}
+
+// WithSlowCallRate with the slow call rate threshold in range (0, 1], default is 1.
+// When the slow call rate is equal or greater than the threshold, the breaker
+// transitions to open.
+func WithSlowCallRate(r float64) Option {
+	return func(o *options) {
+		o.slowCallRate = r
+	}
+}
+
+// WithSlowCallDuration with the duration above which calls are considered as slow,
+// slow call detection is disabled by default.
+func WithSlowCallDuration(d time.Duration) Option {
+	return func(o *options) {
+		o.slowCallDuration = d
+	}
+}
+
+// WithRequest with the minimum number of requests required before the
+// failure rate and slow call rate can be calculated.
+func WithRequest(r int64) Option {
+	return func(o *options) {
+		o.request = r
+	}
+}
+
+// WithWindow with the duration size of the statistical window.
+func WithWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.window = d
+	}
+}
+
+// WithBucket set the bucket number in a window duration.
+func WithBucket(b int) Option {
+	return func(o *options) {
+		o.bucket = b
+	}
+}
+
+// WithOpenWait with the duration the breaker stays open before
+// it transitions to half-open.
+func WithOpenWait(d time.Duration) Option {
+	return func(o *options) {
+		o.openWait = d
+	}
+}
+
+// WithHalfOpenCalls with the number of probe calls permitted in half-open state.
+func WithHalfOpenCalls(n int64) Option {
+	return func(o *options) {
+		o.halfOpenCalls = n
+	}
+}
+
+// Breaker is a classic three-state CircuitBreaker pattern.
+//
+// closed: requests are allowed, the breaker calculates the failure rate and
+// slow call rate within the window, once either exceeds the threshold,
+// the state transitions to open.
+// open: requests are rejected, after the open wait duration the state
+// transitions to half-open.
+// half-open: a limited number of probe requests are allowed, once all
+// probes are finished the state transitions to closed or open again
+// based on their failure rate and slow call rate.
+type Breaker struct {
+	*fsm.Machine
+	opts options
+}
+
+// trip is the trip condition of the breaker.
+type trip struct {
+	stat     window.RollingCounter
+	slowStat window.RollingCounter
+
+	// probe results are the half-open statistics
+	probeResults  int64
+	probeFailures int64
+	probeSlows    int64
+
+	opts options
+}
+
+// NewBreaker return a classic breaker with options.
+func NewBreaker(opts ...Option) *Breaker {
+	opt := options{
+		failureRate:   0.5,
+		slowCallRate:  1,
+		request:       20,
+		bucket:        10,
+		window:        10 * time.Second,
+		openWait:      5 * time.Second,
+		halfOpenCalls: 10,
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	opt.clock = clock.OrNew(opt.clock)
+	return &Breaker{
+		Machine: fsm.New(&trip{opts: opt}, fsm.Options{
+			Name:          opt.name,
+			OnStateChange: opt.onStateChange,
+			Clock:         opt.clock,
+			OpenWait:      opt.openWait,
+			Probes:        opt.halfOpenCalls,
+		}),
+		opts: opt,
+	}
+}
+
+// MarkSuccess mark request is success.
+func (b *Breaker) MarkSuccess() {
+	b.Mark(false, false)
+}
+
+// MarkFailed mark request is failed.
+func (b *Breaker) MarkFailed() {
+	b.Mark(true, false)
+}
+
+// MarkDone mark request is done with its latency, the request is considered
+// as failed if err is not nil, and as slow if latency exceeds the slow call duration.
+func (b *Breaker) MarkDone(latency time.Duration, err error) {
+	slow := b.opts.slowCallDuration > 0 && latency > b.opts.slowCallDuration
+	b.Mark(err != nil, slow)
+}
+
+func (t *trip) Reset(state circuitbreaker.State) {
+	t.probeResults = 0
+	t.probeFailures = 0
+	t.probeSlows = 0
+	if state != circuitbreaker.StateClosed {
+		return
+	}
+	counterOpts := window.RollingCounterOpts{
+		Size:           t.opts.bucket,
+		BucketDuration: time.Duration(int64(t.opts.window) / int64(t.opts.bucket)),
+		Clock:          t.opts.clock,
+	}
+	t.stat = window.NewRollingCounter(counterOpts)
+	t.slowStat = window.NewRollingCounter(counterOpts)
+}
+
+func (t *trip) Mark(state circuitbreaker.State, failed, slow bool) circuitbreaker.State {
+	switch state {
+	case circuitbreaker.StateClosed:
+		t.stat.Add(boolToInt(failed))
+		t.slowStat.Add(boolToInt(slow))
+		if !failed && !slow {
+			// the rates can only exceed the thresholds on failed or slow requests
+			return state
+		}
+		failures, slows, total := t.summary()
+		if total >= t.opts.request && t.exceeded(failures, slows, total) {
+			return circuitbreaker.StateOpen
+		}
+	case circuitbreaker.StateHalfOpen:
+		t.probeResults++
+		t.probeFailures += boolToInt(failed)
+		t.probeSlows += boolToInt(slow)
+		if t.probeFailures == 0 && t.probeSlows == 0 && t.probeResults < t.opts.halfOpenCalls {
+			return state
+		}
+		if t.exceeded(t.probeFailures, t.probeSlows, t.opts.halfOpenCalls) {
+			return circuitbreaker.StateOpen
+		}
+		if t.probeResults >= t.opts.halfOpenCalls {
+			return circuitbreaker.StateClosed
+		}
+	}
+	return state
+}
+
+// summary returns the failed, slow and total requests within the window.
+func (t *trip) summary() (failed, slow, total int64) {
+	t.stat.Reduce(func(iterator window.Iterator) float64 {
+		for iterator.Next() {
+			bucket := iterator.Bucket()
+			total += bucket.Count
+			for _, p := range bucket.Points {
+				failed += int64(p)
+			}
+		}
+		return 0
+	})
+	slow = int64(t.slowStat.Sum())
+	return
+}
+
+// exceeded reports whether the failure rate or slow call rate reaches the threshold.
+func (t *trip) exceeded(failures, slows, total int64) bool {
+	if total <= 0 {
+		return false
+	}
+	return float64(failures)/float64(total) >= t.opts.failureRate ||
+		float64(slows)/float64(total) >= t.opts.slowCallRate
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
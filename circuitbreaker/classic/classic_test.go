@@ -0,0 +1,201 @@
+package classic
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func getClassicBreaker(opts ...Option) *Breaker {
+	return NewBreaker(append([]Option{
+		WithRequest(10),
+		WithWindow(time.Second),
+		WithBucket(10),
+		WithOpenWait(100 * time.Millisecond),
+		WithHalfOpenCalls(3),
+	}, opts...)...)
+}
+
+func markSuccess(b *Breaker, count int) {
+	for i := 0; i < count; i++ {
+		b.MarkSuccess()
+	}
+}
+
+func markFailed(b *Breaker, count int) {
+	for i := 0; i < count; i++ {
+		b.MarkFailed()
+	}
+}
+
+func TestClassicClose(t *testing.T) {
+	b := getClassicBreaker()
+	markFailed(b, 9)
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	assert.Nil(t, b.Allow())
+
+	b = getClassicBreaker()
+	markSuccess(b, 60)
+	markFailed(b, 40)
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	assert.Nil(t, b.Allow())
+}
+
+func TestClassicOpen(t *testing.T) {
+	b := getClassicBreaker()
+	markSuccess(b, 5)
+	markFailed(b, 5)
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+	// results reported after open are ignored
+	markSuccess(b, 100)
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+}
+
+func TestClassicSlowCall(t *testing.T) {
+	b := getClassicBreaker(
+		WithSlowCallDuration(100*time.Millisecond),
+		WithSlowCallRate(0.5),
+	)
+	for i := 0; i < 5; i++ {
+		b.MarkDone(10*time.Millisecond, nil)
+	}
+	for i := 0; i < 4; i++ {
+		b.MarkDone(time.Second, nil)
+	}
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	b.MarkDone(time.Second, nil)
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+
+	b = getClassicBreaker()
+	for i := 0; i < 10; i++ {
+		b.MarkDone(time.Hour, nil)
+	}
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	markFailed(b, 10)
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+
+	b = getClassicBreaker()
+	for i := 0; i < 10; i++ {
+		b.MarkDone(0, errors.New("failed"))
+	}
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+}
+
+func TestClassicHalfOpen(t *testing.T) {
+	t.Run("probes succeed", func(t *testing.T) {
+		c := clock.NewFake(time.Now())
+		b := getClassicBreaker(WithClock(c))
+		markFailed(b, 10)
+		assert.Equal(t, circuitbreaker.StateOpen, b.State())
+		c.Advance(100 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, b.Allow())
+			assert.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+		}
+		// probe calls are bounded
+		assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+		markSuccess(b, 2)
+		assert.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+		markSuccess(b, 1)
+		assert.Equal(t, circuitbreaker.StateClosed, b.State())
+		// statistics are reset after closed
+		markFailed(b, 9)
+		assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	})
+
+	t.Run("probes failed", func(t *testing.T) {
+		c := clock.NewFake(time.Now())
+		b := getClassicBreaker(WithClock(c))
+		markFailed(b, 10)
+		c.Advance(100 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, b.Allow())
+		}
+		markSuccess(b, 1)
+		markFailed(b, 1)
+		assert.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+		markFailed(b, 1)
+		assert.Equal(t, circuitbreaker.StateOpen, b.State())
+		assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+	})
+
+	t.Run("probes lost", func(t *testing.T) {
+		c := clock.NewFake(time.Now())
+		b := getClassicBreaker(WithClock(c))
+		markFailed(b, 10)
+		c.Advance(100 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, b.Allow())
+		}
+		assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+		c.Advance(100 * time.Millisecond)
+		assert.Nil(t, b.Allow())
+		assert.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+	})
+}
+
+func TestClassicWithClock(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	b := getClassicBreaker(WithClock(c), WithOpenWait(time.Minute))
+	markFailed(b, 5)
+	// failures are expired after the window
+	c.Advance(time.Second)
+	markFailed(b, 5)
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	markFailed(b, 5)
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+	c.Advance(59 * time.Second)
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+	c.Advance(time.Second)
+	assert.Nil(t, b.Allow())
+	assert.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+}
+
+func TestClassicStateChange(t *testing.T) {
+	var transitions [][2]circuitbreaker.State
+	c := clock.NewFake(time.Now())
+	b := getClassicBreaker(
+		WithClock(c),
+		WithName("test"),
+		OnStateChange(func(name string, from, to circuitbreaker.State) {
+			assert.Equal(t, "test", name)
+			transitions = append(transitions, [2]circuitbreaker.State{from, to})
+		}),
+	)
+	markFailed(b, 10)
+	c.Advance(100 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		_ = b.Allow()
+	}
+	markSuccess(b, 3)
+	assert.Equal(t, [][2]circuitbreaker.State{
+		{circuitbreaker.StateClosed, circuitbreaker.StateOpen},
+		{circuitbreaker.StateOpen, circuitbreaker.StateHalfOpen},
+		{circuitbreaker.StateHalfOpen, circuitbreaker.StateClosed},
+	}, transitions)
+}
+
+func TestStateString(t *testing.T) {
+	assert.Equal(t, "open", circuitbreaker.StateOpen.String())
+	assert.Equal(t, "closed", circuitbreaker.StateClosed.String())
+	assert.Equal(t, "half-open", circuitbreaker.StateHalfOpen.String())
+	assert.Equal(t, "unknown", circuitbreaker.State(-1).String())
+}
+
+func BenchmarkClassicBreakerAllow(b *testing.B) {
+	breaker := getClassicBreaker(WithRequest(100))
+	b.ResetTimer()
+	for i := 0; i <= b.N; i++ {
+		_ = breaker.Allow()
+		if i%2 == 0 {
+			breaker.MarkSuccess()
+		} else {
+			breaker.MarkFailed()
+		}
+	}
+}
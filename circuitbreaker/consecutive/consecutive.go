@@ -0,0 +1,154 @@
+package consecutive
+
+import (
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/circuitbreaker/internal/fsm"
+	"github.com/go-kratos/aegis/clock"
+)
+
+// Option is consecutive breaker option function.
+type Option func(*options)
+
+var (
+	_ circuitbreaker.StateCircuitBreaker = (*Breaker)(nil)
+)
+
+// options is a breaker options.
+type options struct {
+	name          string
+	onStateChange circuitbreaker.StateChangeFunc
+	clock         clock.Clock
+
+	failures  int64
+	successes int64
+	openWait  time.Duration
+}
+
+// WithClock with the time source of the breaker, default is the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithName with the name of the breaker passed to the state change callback.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// OnStateChange with the callback called on every state transition,
+// it must not call back into the breaker.
+func OnStateChange(f circuitbreaker.StateChangeFunc) Option {
+	return func(o *options) {
+		o.onStateChange = f
+	}
+}
+
+// WithFailures with the number of consecutive failures to open the breaker, default is 5.
+func WithFailures(n int64) Option {
+	return func(o *options) {
+		o.failures = n
+	}
+}
+
+// WithSuccesses with the number of consecutive successful probes in
+// half-open state to close the breaker, default is 1.
+func WithSuccesses(n int64) Option {
+	return func(o *options) {
+		o.successes = n
+	}
+}
+
+// WithOpenWait with the duration the breaker stays open before
+// it transitions to half-open.
+func WithOpenWait(d time.Duration) Option {
+	return func(o *options) {
+		o.openWait = d
+	}
+}
+
+// Breaker is a CircuitBreaker pattern which opens after consecutive failures.
+// It does not require a minimum number of requests, so it fits low-QPS backends.
+//
+// closed: requests are allowed, the breaker opens once the number of
+// consecutive failures reaches the threshold.
+// open: requests are rejected, after the open wait duration the state
+// transitions to half-open.
+// half-open: one probe request is allowed at a time, the breaker closes after
+// consecutive successful probes, and opens again on any failed probe.
+type Breaker struct {
+	*fsm.Machine
+}
+
+// trip is the trip condition of the breaker.
+type trip struct {
+	// failures is the number of consecutive failures in closed state,
+	// successes is the number of consecutive successful probes in half-open state.
+	failures  int64
+	successes int64
+
+	opts options
+}
+
+// NewBreaker return a consecutive breaker with options.
+func NewBreaker(opts ...Option) *Breaker {
+	opt := options{
+		failures:  5,
+		successes: 1,
+		openWait:  10 * time.Second,
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	return &Breaker{
+		Machine: fsm.New(&trip{opts: opt}, fsm.Options{
+			Name:          opt.name,
+			OnStateChange: opt.onStateChange,
+			Clock:         opt.clock,
+			OpenWait:      opt.openWait,
+			Release:       true,
+		}),
+	}
+}
+
+// MarkSuccess mark request is success.
+func (b *Breaker) MarkSuccess() {
+	b.Mark(false, false)
+}
+
+// MarkFailed mark request is failed.
+func (b *Breaker) MarkFailed() {
+	b.Mark(true, false)
+}
+
+func (t *trip) Reset(circuitbreaker.State) {
+	t.failures = 0
+	t.successes = 0
+}
+
+func (t *trip) Mark(state circuitbreaker.State, failed, _ bool) circuitbreaker.State {
+	switch state {
+	case circuitbreaker.StateClosed:
+		if !failed {
+			t.failures = 0
+			return state
+		}
+		t.failures++
+		if t.failures >= t.opts.failures {
+			return circuitbreaker.StateOpen
+		}
+	case circuitbreaker.StateHalfOpen:
+		if failed {
+			return circuitbreaker.StateOpen
+		}
+		t.successes++
+		if t.successes >= t.opts.successes {
+			return circuitbreaker.StateClosed
+		}
+	}
+	return state
+}
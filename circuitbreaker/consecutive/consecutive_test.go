@@ -0,0 +1,98 @@
+package consecutive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func getConsecutiveBreaker(opts ...Option) *Breaker {
+	return NewBreaker(append([]Option{
+		WithFailures(3),
+		WithOpenWait(100 * time.Millisecond),
+	}, opts...)...)
+}
+
+func TestConsecutiveClose(t *testing.T) {
+	b := getConsecutiveBreaker()
+	for i := 0; i < 10; i++ {
+		b.MarkFailed()
+		b.MarkFailed()
+		b.MarkSuccess()
+	}
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	assert.Nil(t, b.Allow())
+}
+
+func TestConsecutiveOpen(t *testing.T) {
+	b := getConsecutiveBreaker()
+	b.MarkFailed()
+	b.MarkFailed()
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	b.MarkFailed()
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+}
+
+func TestConsecutiveHalfOpen(t *testing.T) {
+	t.Run("probe succeed", func(t *testing.T) {
+		b := getConsecutiveBreaker(WithSuccesses(2))
+		for i := 0; i < 3; i++ {
+			b.MarkFailed()
+		}
+		time.Sleep(150 * time.Millisecond)
+		assert.Nil(t, b.Allow())
+		assert.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+		// only one probe at a time
+		assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+		b.MarkSuccess()
+		assert.Equal(t, circuitbreaker.StateHalfOpen, b.State())
+		assert.Nil(t, b.Allow())
+		b.MarkSuccess()
+		assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	})
+
+	t.Run("probe failed", func(t *testing.T) {
+		b := getConsecutiveBreaker()
+		for i := 0; i < 3; i++ {
+			b.MarkFailed()
+		}
+		time.Sleep(150 * time.Millisecond)
+		assert.Nil(t, b.Allow())
+		b.MarkFailed()
+		assert.Equal(t, circuitbreaker.StateOpen, b.State())
+		assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+	})
+
+	t.Run("probe lost", func(t *testing.T) {
+		b := getConsecutiveBreaker()
+		for i := 0; i < 3; i++ {
+			b.MarkFailed()
+		}
+		time.Sleep(150 * time.Millisecond)
+		assert.Nil(t, b.Allow())
+		assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+		time.Sleep(150 * time.Millisecond)
+		assert.Nil(t, b.Allow())
+	})
+}
+
+func TestConsecutiveStateChange(t *testing.T) {
+	var transitions [][2]circuitbreaker.State
+	b := getConsecutiveBreaker(OnStateChange(func(_ string, from, to circuitbreaker.State) {
+		transitions = append(transitions, [2]circuitbreaker.State{from, to})
+	}))
+	for i := 0; i < 3; i++ {
+		b.MarkFailed()
+	}
+	time.Sleep(150 * time.Millisecond)
+	_ = b.Allow()
+	b.MarkSuccess()
+	assert.Equal(t, [][2]circuitbreaker.State{
+		{circuitbreaker.StateClosed, circuitbreaker.StateOpen},
+		{circuitbreaker.StateOpen, circuitbreaker.StateHalfOpen},
+		{circuitbreaker.StateHalfOpen, circuitbreaker.StateClosed},
+	}, transitions)
+}
@@ -0,0 +1,88 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DoOption is Do option function.
+type DoOption func(*doOptions)
+
+// doOptions is Do options.
+type doOptions struct {
+	isSuccessful func(error) bool
+	isIgnored    func(error) bool
+}
+
+// WithIsSuccessful with the classifier which reports whether the error
+// returned by the call is considered as a success, default only nil is a success.
+func WithIsSuccessful(f func(err error) bool) DoOption {
+	return func(o *doOptions) {
+		o.isSuccessful = f
+	}
+}
+
+// WithIsIgnored with the classifier which reports whether the error returned
+// by the call is neither a success nor a failure, default context.Canceled is ignored
+// since it is caused by the client rather than the backend.
+func WithIsIgnored(f func(err error) bool) DoOption {
+	return func(o *doOptions) {
+		o.isIgnored = f
+	}
+}
+
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// Do calls fn if the breaker allows, and marks the result of the call.
+// A panic in fn is marked as failed and then re-panicked.
+// If cb is a LatencyCircuitBreaker, the latency of the call is marked as well.
+// If the call is rejected, it is marked to cb if cb is a RejectionMarker.
+func Do[T any](ctx context.Context, cb CircuitBreaker, fn func(ctx context.Context) (T, error), opts ...DoOption) (result T, err error) {
+	return DoWithFallback(ctx, cb, fn, nil, opts...)
+}
+
+// DoWithFallback is Do with the fallback called when the call is rejected by the breaker.
+func DoWithFallback[T any](ctx context.Context, cb CircuitBreaker, fn func(ctx context.Context) (T, error), fallback func(ctx context.Context, err error) (T, error), opts ...DoOption) (result T, err error) {
+	o := doOptions{
+		isSuccessful: func(err error) bool { return err == nil },
+		isIgnored:    isCanceled,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err = cb.Allow(); err != nil {
+		MarkRejected(cb)
+		if fallback == nil {
+			return result, err
+		}
+		return fallback(ctx, err)
+	}
+	start := time.Now()
+	defer func() {
+		if p := recover(); p != nil {
+			MarkDone(cb, time.Since(start), fmt.Errorf("circuitbreaker: panic: %v", p))
+			panic(p)
+		}
+	}()
+	result, err = fn(ctx)
+	switch {
+	case o.isIgnored(err):
+	case o.isSuccessful(err):
+		MarkDone(cb, time.Since(start), nil)
+	default:
+		MarkDone(cb, time.Since(start), err)
+	}
+	return result, err
+}
+
+// Execute is Do for calls without result.
+func Execute(ctx context.Context, cb CircuitBreaker, fn func(ctx context.Context) error, opts ...DoOption) error {
+	_, err := Do(ctx, cb, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, opts...)
+	return err
+}
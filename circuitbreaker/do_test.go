@@ -0,0 +1,160 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBreaker struct {
+	allow   error
+	success int
+	failed  int
+}
+
+func (b *mockBreaker) Allow() error { return b.allow }
+func (b *mockBreaker) MarkSuccess() { b.success++ }
+func (b *mockBreaker) MarkFailed()  { b.failed++ }
+
+type mockLatencyBreaker struct {
+	mockBreaker
+	latency time.Duration
+	err     error
+}
+
+func (b *mockLatencyBreaker) MarkDone(latency time.Duration, err error) {
+	b.latency = latency
+	b.err = err
+}
+
+func TestDoWithLatency(t *testing.T) {
+	cb := &mockLatencyBreaker{}
+	errBackend := errors.New("backend error")
+	err := circuitbreaker.Execute(context.Background(), cb, func(context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		return errBackend
+	})
+	assert.Equal(t, errBackend, err)
+	assert.Equal(t, errBackend, cb.err)
+	assert.GreaterOrEqual(t, cb.latency, 10*time.Millisecond)
+	assert.Equal(t, 0, cb.success+cb.failed)
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+	errBackend := errors.New("backend error")
+
+	t.Run("success", func(t *testing.T) {
+		cb := &mockBreaker{}
+		v, err := circuitbreaker.Do(ctx, cb, func(context.Context) (string, error) {
+			return "ok", nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "ok", v)
+		assert.Equal(t, 1, cb.success)
+		assert.Equal(t, 0, cb.failed)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		cb := &mockBreaker{}
+		_, err := circuitbreaker.Do(ctx, cb, func(context.Context) (string, error) {
+			return "", errBackend
+		})
+		assert.Equal(t, errBackend, err)
+		assert.Equal(t, 0, cb.success)
+		assert.Equal(t, 1, cb.failed)
+	})
+
+	t.Run("classifier", func(t *testing.T) {
+		cb := &mockBreaker{}
+		err := circuitbreaker.Execute(ctx, cb, func(context.Context) error {
+			return errBackend
+		}, circuitbreaker.WithIsSuccessful(func(err error) bool {
+			return err == nil || errors.Is(err, errBackend)
+		}))
+		assert.Equal(t, errBackend, err)
+		assert.Equal(t, 1, cb.success)
+		assert.Equal(t, 0, cb.failed)
+	})
+
+	t.Run("ignore canceled", func(t *testing.T) {
+		cb := &mockBreaker{}
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		err := circuitbreaker.Execute(cctx, cb, func(ctx context.Context) error {
+			return ctx.Err()
+		})
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, 0, cb.success)
+		assert.Equal(t, 0, cb.failed)
+
+		err = circuitbreaker.Execute(cctx, cb, func(ctx context.Context) error {
+			return ctx.Err()
+		}, circuitbreaker.WithIsIgnored(func(error) bool { return false }))
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, 1, cb.failed)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		cb := &mockBreaker{}
+		assert.PanicsWithValue(t, "boom", func() {
+			_ = circuitbreaker.Execute(ctx, cb, func(context.Context) error {
+				panic("boom")
+			})
+		})
+		assert.Equal(t, 0, cb.success)
+		assert.Equal(t, 1, cb.failed)
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		cb := &mockBreaker{allow: circuitbreaker.ErrNotAllowed}
+		called := false
+		_, err := circuitbreaker.Do(ctx, cb, func(context.Context) (int, error) {
+			called = true
+			return 1, nil
+		})
+		assert.Equal(t, circuitbreaker.ErrNotAllowed, err)
+		assert.False(t, called)
+
+		v, err := circuitbreaker.DoWithFallback(ctx, cb, func(context.Context) (int, error) {
+			called = true
+			return 1, nil
+		}, func(_ context.Context, err error) (int, error) {
+			assert.Equal(t, circuitbreaker.ErrNotAllowed, err)
+			return 2, nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, v)
+		assert.False(t, called)
+		assert.Equal(t, 0, cb.success+cb.failed)
+	})
+}
+
+type mockRejectionBreaker struct {
+	mockBreaker
+	rejected int
+}
+
+func (b *mockRejectionBreaker) MarkRejected() { b.rejected++ }
+
+func TestDoMarkRejected(t *testing.T) {
+	cb := &mockRejectionBreaker{mockBreaker: mockBreaker{allow: circuitbreaker.ErrNotAllowed}}
+	err := circuitbreaker.Execute(context.Background(), cb, func(context.Context) error {
+		return nil
+	})
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, err)
+	assert.Equal(t, 1, cb.rejected)
+	assert.Equal(t, 0, cb.success+cb.failed)
+
+	cb.allow = nil
+	err = circuitbreaker.Execute(context.Background(), cb, func(context.Context) error {
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cb.rejected)
+	assert.Equal(t, 1, cb.success)
+}
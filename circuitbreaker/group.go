@@ -1,32 +1,149 @@
 package circuitbreaker
 
 import (
-	"github.com/go-kratos/aegis/internal/syncmap"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/internal/lru"
 )
 
 // CircuitBreakerFactory 定义一个闭包类型，用于创建 CircuitBreaker 实例
 type CircuitBreakerFactory func() CircuitBreaker
 
+// GroupOption is a function that configures the Group.
+type GroupOption func(*Group)
+
+// WithCapacity sets the maximum number of circuit breakers in the group,
+// the least recently used one is evicted once exceeded.
+// Zero means no limit, which is the default.
+func WithCapacity(n int) GroupOption {
+	return func(g *Group) {
+		g.capacity = n
+	}
+}
+
+// WithExpires sets the idle duration after which a circuit breaker is evicted.
+// Zero means never expire, which is the default.
+func WithExpires(d time.Duration) GroupOption {
+	return func(g *Group) {
+		g.expires = d
+	}
+}
+
+// WithClock sets the time source of the group, default is the wall clock.
+func WithClock(c clock.Clock) GroupOption {
+	return func(g *Group) {
+		g.clock = c
+	}
+}
+
 // Group is a circuit breaker that manages multiple circuit breakers by key.
+// The lookups of the existing keys are lock-free, and the overridden circuit
+// breakers are never evicted, so an override outlives the capacity and the expiry.
 type Group struct {
-	requests  syncmap.SyncMap[string, CircuitBreaker]
+	breakers  *lru.Cache[CircuitBreaker]
 	cbFactory CircuitBreakerFactory
+
+	capacity int
+	expires  time.Duration
+	clock    clock.Clock
 }
 
-// NewGroupCircuitBreaker creates a new Group with the given factory.
-func NewGroup(factory CircuitBreakerFactory) *Group {
+// NewGroup creates a new Group with the given factory.
+func NewGroup(factory CircuitBreakerFactory, opts ...GroupOption) *Group {
 	g := &Group{
 		cbFactory: factory,
 	}
+	for _, o := range opts {
+		o(g)
+	}
+	g.clock = clock.OrNew(g.clock)
+	g.breakers = lru.New(lru.Options[CircuitBreaker]{
+		Capacity: g.capacity,
+		Expires:  g.expires,
+		Clock:    g.clock,
+		Pinned:   g.overridden,
+	})
 	return g
 }
 
+// overridden reports whether the circuit breaker is overridden manually.
+func (g *Group) overridden(cb CircuitBreaker) bool {
+	o, ok := asOverrider(cb)
+	return ok && o.Override() != OverrideNone
+}
+
+// asOverrider returns the Overrider of the innermost breaker wrapped by cb,
+// so the wrappers forwarding the overrides are supported only if it is.
+func asOverrider(cb CircuitBreaker) (Overrider, bool) {
+	for {
+		w, ok := cb.(Wrapper)
+		if !ok {
+			break
+		}
+		cb = w.Unwrap()
+	}
+	o, ok := cb.(Overrider)
+	return o, ok
+}
+
 // GetCircuitBreaker returns a CircuitBreaker for the given key.
 func (g *Group) GetCircuitBreaker(key string) CircuitBreaker {
-	cb, ok := g.requests.Load(key)
+	// 使用传入的闭包创建具体的 CircuitBreaker 实例
+	return g.breakers.GetOrCreate(key, g.cbFactory)
+}
+
+// Remove removes the circuit breaker of the given key,
+// it returns false if the key does not exist.
+func (g *Group) Remove(key string) bool {
+	return g.breakers.Remove(key)
+}
+
+// Range calls f sequentially for each key and circuit breaker in the group,
+// from the most to the least recently used. If f returns false, range stops the iteration.
+func (g *Group) Range(f func(key string, cb CircuitBreaker) bool) {
+	// f is called without the lock held, so it is able to remove keys
+	g.breakers.Range(f)
+}
+
+// Len returns the number of circuit breakers in the group.
+func (g *Group) Len() int {
+	return g.breakers.Len()
+}
+
+// ForceOpen pins the circuit breaker of the given key open for d, zero means until Reset.
+// The circuit breaker is created if the key does not exist, and it is not evicted
+// from the group until the override is reset or expired.
+func (g *Group) ForceOpen(key string, d time.Duration) error {
+	o, ok := asOverrider(g.GetCircuitBreaker(key))
+	if !ok {
+		return ErrOverrideNotSupported
+	}
+	o.ForceOpen(d)
+	return nil
+}
+
+// ForceClose pins the circuit breaker of the given key closed for d, zero means until Reset.
+func (g *Group) ForceClose(key string, d time.Duration) error {
+	o, ok := asOverrider(g.GetCircuitBreaker(key))
+	if !ok {
+		return ErrOverrideNotSupported
+	}
+	o.ForceClose(d)
+	return nil
+}
+
+// Reset removes the override of the circuit breaker of the given key,
+// it is a no-op if the key does not exist.
+func (g *Group) Reset(key string) error {
+	cb, ok := g.breakers.Get(key)
+	if !ok {
+		return nil
+	}
+	o, ok := asOverrider(cb)
 	if !ok {
-		// 使用传入的闭包创建具体的 CircuitBreaker 实例
-		cb, _ = g.requests.LoadOrStore(key, g.cbFactory())
+		return ErrOverrideNotSupported
 	}
-	return cb
+	o.Reset()
+	return nil
 }
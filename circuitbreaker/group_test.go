@@ -2,6 +2,7 @@ package circuitbreaker_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/go-kratos/aegis/circuitbreaker"
 	"github.com/go-kratos/aegis/circuitbreaker/sre"
@@ -21,6 +22,112 @@ func TestGroup_GetCircuitBreaker(t *testing.T) {
 	assert.Equal(t, succ.Allow(), nil)
 	assert.NotEqual(t, fail.Allow(), nil)
 }
+func TestGroup_Capacity(t *testing.T) {
+	g := circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return sre.NewBreaker()
+	}, circuitbreaker.WithCapacity(2))
+	a := g.GetCircuitBreaker("a")
+	g.GetCircuitBreaker("b")
+	// a becomes the most recently used
+	assert.Same(t, a, g.GetCircuitBreaker("a"))
+	g.GetCircuitBreaker("c")
+	assert.Equal(t, 2, g.Len())
+
+	var keys []string
+	g.Range(func(key string, _ circuitbreaker.CircuitBreaker) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.Equal(t, []string{"c", "a"}, keys)
+}
+
+func TestGroup_Expires(t *testing.T) {
+	g := circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return sre.NewBreaker()
+	}, circuitbreaker.WithExpires(100*time.Millisecond))
+	a := g.GetCircuitBreaker("a")
+	g.GetCircuitBreaker("b")
+	time.Sleep(60 * time.Millisecond)
+	g.GetCircuitBreaker("b")
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, 1, g.Len())
+	assert.NotSame(t, a, g.GetCircuitBreaker("a"))
+	assert.Equal(t, 2, g.Len())
+}
+
+func TestGroup_Remove(t *testing.T) {
+	g := circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return sre.NewBreaker()
+	})
+	g.GetCircuitBreaker("a")
+	g.GetCircuitBreaker("b")
+	g.GetCircuitBreaker("c")
+	assert.True(t, g.Remove("a"))
+	assert.False(t, g.Remove("a"))
+	assert.Equal(t, 2, g.Len())
+
+	// remove keys while ranging
+	g.Range(func(key string, _ circuitbreaker.CircuitBreaker) bool {
+		g.Remove(key)
+		return false
+	})
+	assert.Equal(t, 1, g.Len())
+}
+
+func TestGroup_Override(t *testing.T) {
+	g := circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return sre.NewBreaker()
+	})
+	assert.Nil(t, g.ForceOpen("a", 0))
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, g.GetCircuitBreaker("a").Allow())
+	assert.Nil(t, g.GetCircuitBreaker("b").Allow())
+	assert.Nil(t, g.ForceClose("a", 0))
+	assert.Nil(t, g.GetCircuitBreaker("a").Allow())
+	assert.Nil(t, g.Reset("a"))
+	assert.Equal(t, circuitbreaker.OverrideNone, g.GetCircuitBreaker("a").(circuitbreaker.Overrider).Override())
+
+	g = circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return &mockBreaker{}
+	})
+	assert.Equal(t, circuitbreaker.ErrOverrideNotSupported, g.ForceOpen("a", 0))
+	assert.Equal(t, circuitbreaker.ErrOverrideNotSupported, g.ForceClose("a", 0))
+	assert.Equal(t, circuitbreaker.ErrOverrideNotSupported, g.Reset("a"))
+}
+
+func TestGroup_OverrideEviction(t *testing.T) {
+	g := circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return sre.NewBreaker()
+	}, circuitbreaker.WithCapacity(1))
+	// reset does not create the unknown keys
+	assert.Nil(t, g.Reset("typo"))
+	assert.Equal(t, 0, g.Len())
+
+	assert.Nil(t, g.ForceOpen("a", 0))
+	g.GetCircuitBreaker("b")
+	g.GetCircuitBreaker("c")
+	// the overridden breaker is not evicted
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, g.GetCircuitBreaker("a").Allow())
+	assert.Nil(t, g.Reset("a"))
+	g.GetCircuitBreaker("d")
+	assert.Equal(t, 1, g.Len())
+}
+
+func TestOverride(t *testing.T) {
+	var o circuitbreaker.Override
+	now := time.Now()
+	assert.Equal(t, circuitbreaker.OverrideNone, o.Mode(now))
+	o.Set(circuitbreaker.OverrideForceOpen, now, time.Second)
+	assert.Equal(t, circuitbreaker.OverrideForceOpen, o.Mode(now))
+	assert.Equal(t, circuitbreaker.OverrideNone, o.Mode(now.Add(time.Second)))
+	o.Set(circuitbreaker.OverrideForceClosed, now, 0)
+	assert.Equal(t, circuitbreaker.OverrideForceClosed, o.Mode(now.Add(time.Hour)))
+
+	assert.Equal(t, "none", circuitbreaker.OverrideNone.String())
+	assert.Equal(t, "force-open", circuitbreaker.OverrideForceOpen.String())
+	assert.Equal(t, "force-closed", circuitbreaker.OverrideForceClosed.String())
+	assert.Equal(t, "unknown", circuitbreaker.OverrideMode(-1).String())
+}
+
 func markSuccess(cb circuitbreaker.CircuitBreaker, count int) {
 	for i := 0; i < count; i++ {
 		cb.MarkSuccess()
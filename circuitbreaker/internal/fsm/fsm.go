@@ -0,0 +1,147 @@
+// Package fsm provides the closed/open/half-open state machine shared by
+// the circuit breakers, each breaker only implements its trip condition.
+package fsm
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/clock"
+)
+
+// Trip is the trip condition of a breaker,
+// its methods are called with the machine locked.
+type Trip interface {
+	// Reset resets the statistics once the machine enters the state.
+	Reset(state circuitbreaker.State)
+	// Mark marks the result of a request in closed or half-open state,
+	// and returns the state to transition to.
+	Mark(state circuitbreaker.State, failed, slow bool) circuitbreaker.State
+}
+
+// Options is the options of the machine.
+type Options struct {
+	// Name is the name passed to the state change callback.
+	Name string
+	// OnStateChange is called with the machine locked on every state transition.
+	OnStateChange circuitbreaker.StateChangeFunc
+	// Clock is the time source, default is the wall clock.
+	Clock clock.Clock
+	// OpenWait is the duration the machine stays open before it transitions to half-open.
+	OpenWait time.Duration
+	// Probes is the number of probes allowed in a half-open round, default is 1.
+	Probes int64
+	// Release defines whether a probe is released once its result keeps the
+	// machine half-open, so Probes limits the in-flight probes instead.
+	Release bool
+}
+
+// Machine is the state machine of a circuit breaker.
+//
+// closed: requests are allowed, the trip condition decides when to open.
+// open: requests are rejected, after the open wait duration the state
+// transitions to half-open.
+// half-open: a limited number of probe requests are allowed, the trip
+// condition decides to close or open again by their results.
+type Machine struct {
+	mu    sync.Mutex
+	state int32
+
+	// stateTime defines when the machine entered the current state or
+	// the last probe was allowed
+	stateTime time.Time
+	// probes is the number of probes allowed in the current half-open round
+	probes int64
+
+	trip Trip
+	opts Options
+}
+
+// New returns a closed machine of the trip condition.
+func New(trip Trip, opts Options) *Machine {
+	if opts.Probes <= 0 {
+		opts.Probes = 1
+	}
+	opts.Clock = clock.OrNew(opts.Clock)
+	trip.Reset(circuitbreaker.StateClosed)
+	return &Machine{
+		state:     int32(circuitbreaker.StateClosed),
+		stateTime: opts.Clock.Now(),
+		trip:      trip,
+		opts:      opts,
+	}
+}
+
+// State returns the current state of the machine.
+func (m *Machine) State() circuitbreaker.State {
+	return circuitbreaker.State(atomic.LoadInt32(&m.state))
+}
+
+// Allow request if error returns nil.
+func (m *Machine) Allow() error {
+	if m.State() == circuitbreaker.StateClosed {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.opts.Clock.Now()
+	switch circuitbreaker.State(m.state) {
+	case circuitbreaker.StateClosed:
+		return nil
+	case circuitbreaker.StateOpen:
+		if now.Sub(m.stateTime) < m.opts.OpenWait {
+			return circuitbreaker.ErrNotAllowed
+		}
+		m.setState(circuitbreaker.StateHalfOpen, now)
+	}
+	if m.probes >= m.opts.Probes {
+		// NOTE: probes which never report their result would keep the
+		// machine half-open forever, start a new round of probes after
+		// the open wait duration.
+		if now.Sub(m.stateTime) < m.opts.OpenWait {
+			return circuitbreaker.ErrNotAllowed
+		}
+		m.setState(circuitbreaker.StateHalfOpen, now)
+	}
+	m.probes++
+	m.stateTime = now
+	return nil
+}
+
+// Mark marks the result of a request, the results in open state are ignored.
+func (m *Machine) Mark(failed, slow bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	from := circuitbreaker.State(m.state)
+	if from == circuitbreaker.StateOpen {
+		return
+	}
+	to := m.trip.Mark(from, failed, slow)
+	if to != from {
+		m.setState(to, m.opts.Clock.Now())
+		return
+	}
+	if from == circuitbreaker.StateHalfOpen && m.opts.Release && m.probes > 0 {
+		m.probes--
+	}
+}
+
+// Locked calls f with the machine locked, e.g. to read the statistics of the trip condition.
+func (m *Machine) Locked(f func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f()
+}
+
+// setState must be called with the lock held.
+func (m *Machine) setState(state circuitbreaker.State, now time.Time) {
+	m.stateTime = now
+	m.probes = 0
+	m.trip.Reset(state)
+	from := circuitbreaker.State(atomic.SwapInt32(&m.state, int32(state)))
+	if from != state && m.opts.OnStateChange != nil {
+		m.opts.OnStateChange(m.opts.Name, from, state)
+	}
+}
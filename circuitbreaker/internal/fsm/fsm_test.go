@@ -0,0 +1,92 @@
+package fsm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockTrip opens on any failure in closed state, and closes on two
+// successful probes in half-open state.
+type mockTrip struct {
+	resets    []circuitbreaker.State
+	successes int
+}
+
+func (t *mockTrip) Reset(state circuitbreaker.State) {
+	t.resets = append(t.resets, state)
+	t.successes = 0
+}
+
+func (t *mockTrip) Mark(state circuitbreaker.State, failed, _ bool) circuitbreaker.State {
+	if failed {
+		return circuitbreaker.StateOpen
+	}
+	if state == circuitbreaker.StateHalfOpen {
+		if t.successes++; t.successes >= 2 {
+			return circuitbreaker.StateClosed
+		}
+	}
+	return state
+}
+
+func TestMachine(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	trip := &mockTrip{}
+	var transitions [][2]circuitbreaker.State
+	m := New(trip, Options{
+		Name:     "test",
+		Clock:    clk,
+		OpenWait: time.Second,
+		Probes:   2,
+		OnStateChange: func(name string, from, to circuitbreaker.State) {
+			assert.Equal(t, "test", name)
+			transitions = append(transitions, [2]circuitbreaker.State{from, to})
+		},
+	})
+	assert.Nil(t, m.Allow())
+	m.Mark(true, false)
+	assert.Equal(t, circuitbreaker.StateOpen, m.State())
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, m.Allow())
+
+	clk.Advance(time.Second)
+	assert.Nil(t, m.Allow())
+	assert.Nil(t, m.Allow())
+	// the probes of the round are exhausted
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, m.Allow())
+	m.Mark(false, false)
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, m.Allow())
+	m.Mark(false, false)
+	assert.Equal(t, circuitbreaker.StateClosed, m.State())
+	assert.Equal(t, [][2]circuitbreaker.State{
+		{circuitbreaker.StateClosed, circuitbreaker.StateOpen},
+		{circuitbreaker.StateOpen, circuitbreaker.StateHalfOpen},
+		{circuitbreaker.StateHalfOpen, circuitbreaker.StateClosed},
+	}, transitions)
+	assert.Equal(t, []circuitbreaker.State{
+		circuitbreaker.StateClosed,
+		circuitbreaker.StateOpen,
+		circuitbreaker.StateHalfOpen,
+		circuitbreaker.StateClosed,
+	}, trip.resets)
+}
+
+func TestMachineRelease(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	m := New(&mockTrip{}, Options{Clock: clk, OpenWait: time.Second, Release: true})
+	m.Mark(true, false)
+	clk.Advance(time.Second)
+	assert.Nil(t, m.Allow())
+	// only one probe at a time
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, m.Allow())
+	m.Mark(false, false)
+	assert.Nil(t, m.Allow())
+
+	// a lost probe is replaced after the open wait duration
+	clk.Advance(time.Second)
+	assert.Nil(t, m.Allow())
+	assert.Equal(t, circuitbreaker.StateHalfOpen, m.State())
+}
@@ -0,0 +1,214 @@
+package latency
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/window"
+)
+
+// ErrSlowCall is marked to the underlying breaker when a call is considered as slow.
+var ErrSlowCall = errors.New("circuitbreaker: slow call")
+
+// Option is latency breaker option function.
+type Option func(*options)
+
+var (
+	_ circuitbreaker.LatencyCircuitBreaker = (*Breaker)(nil)
+	_ circuitbreaker.RejectionMarker       = (*Breaker)(nil)
+	_ circuitbreaker.StateCircuitBreaker   = (*Breaker)(nil)
+	_ circuitbreaker.Overrider             = (*Breaker)(nil)
+	_ circuitbreaker.Wrapper               = (*Breaker)(nil)
+)
+
+// options is a breaker options.
+type options struct {
+	clock clock.Clock
+
+	threshold  time.Duration
+	target     time.Duration
+	percentile float64
+	bucket     int
+	window     time.Duration
+}
+
+// WithClock with the time source of the breaker, default is the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithThreshold with the latency threshold, calls slower than
+// the threshold are marked as failed. It is disabled by default.
+func WithThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.threshold = d
+	}
+}
+
+// WithTarget with the latency target of the rolling percentile, once the
+// percentile exceeds the target, calls slower than the target are marked as failed.
+// It is disabled by default.
+func WithTarget(d time.Duration) Option {
+	return func(o *options) {
+		o.target = d
+	}
+}
+
+// WithPercentile with the percentile in range (0, 1] compared with the target, default is 0.99.
+func WithPercentile(p float64) Option {
+	return func(o *options) {
+		o.percentile = p
+	}
+}
+
+// WithWindow with the duration size of the statistical window.
+func WithWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.window = d
+	}
+}
+
+// WithBucket set the bucket number in a window duration.
+func WithBucket(b int) Option {
+	return func(o *options) {
+		o.bucket = b
+	}
+}
+
+// percentileCache is used to cache the percentile result.
+// Cache time is equal to a bucket duration.
+type percentileCache struct {
+	val  time.Duration
+	time time.Time
+}
+
+// Breaker is a latency-aware CircuitBreaker, it wraps another breaker and
+// treats slow calls as failures, so slow but successful backends are also tripped.
+type Breaker struct {
+	circuitbreaker.CircuitBreaker
+
+	stat            window.RollingPercentile
+	bucketDuration  time.Duration
+	percentileCache atomic.Value
+
+	opts options
+}
+
+// NewBreaker return a latency breaker wrapping cb with options.
+func NewBreaker(cb circuitbreaker.CircuitBreaker, opts ...Option) *Breaker {
+	opt := options{
+		percentile: 0.99,
+		bucket:     10,
+		window:     10 * time.Second,
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	opt.clock = clock.OrNew(opt.clock)
+	bucketDuration := time.Duration(int64(opt.window) / int64(opt.bucket))
+	return &Breaker{
+		CircuitBreaker: cb,
+		stat: window.NewRollingPercentile(window.RollingPercentileOpts{
+			Size:           opt.bucket,
+			BucketDuration: bucketDuration,
+			// from 100µs to about 1min, with the error less than 10%
+			Bounds: window.ExponentialBounds(float64(100*time.Microsecond), 1.1, 140),
+			Clock:  opt.clock,
+		}),
+		bucketDuration: bucketDuration,
+		opts:           opt,
+	}
+}
+
+// Percentile returns the rolling latency percentile within the window.
+func (b *Breaker) Percentile() time.Duration {
+	if cache, ok := b.percentileCache.Load().(*percentileCache); ok {
+		if b.opts.clock.Now().Sub(cache.time) < b.bucketDuration {
+			return cache.val
+		}
+	}
+	val := time.Duration(b.stat.Percentile(b.opts.percentile))
+	b.percentileCache.Store(&percentileCache{
+		val:  val,
+		time: b.opts.clock.Now(),
+	})
+	return val
+}
+
+// slow reports whether the call of the latency is considered as slow.
+func (b *Breaker) slow(latency time.Duration) bool {
+	if b.opts.threshold > 0 && latency > b.opts.threshold {
+		return true
+	}
+	if b.opts.target > 0 {
+		return latency > b.opts.target && b.Percentile() > b.opts.target
+	}
+	return false
+}
+
+// MarkDone mark request is done with its latency.
+func (b *Breaker) MarkDone(latency time.Duration, err error) {
+	if b.opts.target > 0 {
+		b.stat.Add(int64(latency))
+	}
+	if err == nil && b.slow(latency) {
+		err = ErrSlowCall
+	}
+	circuitbreaker.MarkDone(b.CircuitBreaker, latency, err)
+}
+
+// MarkRejected mark request is rejected, it is passed to the underlying
+// breaker if the breaker counts the rejected requests.
+func (b *Breaker) MarkRejected() {
+	circuitbreaker.MarkRejected(b.CircuitBreaker)
+}
+
+// Unwrap returns the underlying breaker.
+func (b *Breaker) Unwrap() circuitbreaker.CircuitBreaker {
+	return b.CircuitBreaker
+}
+
+// State returns the state of the underlying breaker,
+// it is always closed if the breaker does not expose its state.
+func (b *Breaker) State() circuitbreaker.State {
+	if sb, ok := b.CircuitBreaker.(circuitbreaker.StateCircuitBreaker); ok {
+		return sb.State()
+	}
+	return circuitbreaker.StateClosed
+}
+
+// ForceOpen pins the underlying breaker open for d, zero means until Reset.
+// It is a no-op if the underlying breaker is not a circuitbreaker.Overrider.
+func (b *Breaker) ForceOpen(d time.Duration) {
+	if o, ok := b.CircuitBreaker.(circuitbreaker.Overrider); ok {
+		o.ForceOpen(d)
+	}
+}
+
+// ForceClose pins the underlying breaker closed for d, zero means until Reset.
+// It is a no-op if the underlying breaker is not a circuitbreaker.Overrider.
+func (b *Breaker) ForceClose(d time.Duration) {
+	if o, ok := b.CircuitBreaker.(circuitbreaker.Overrider); ok {
+		o.ForceClose(d)
+	}
+}
+
+// Reset removes the override of the underlying breaker.
+func (b *Breaker) Reset() {
+	if o, ok := b.CircuitBreaker.(circuitbreaker.Overrider); ok {
+		o.Reset()
+	}
+}
+
+// Override returns the override mode of the underlying breaker.
+func (b *Breaker) Override() circuitbreaker.OverrideMode {
+	if o, ok := b.CircuitBreaker.(circuitbreaker.Overrider); ok {
+		return o.Override()
+	}
+	return circuitbreaker.OverrideNone
+}
@@ -0,0 +1,106 @@
+package latency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/circuitbreaker/classic"
+	"github.com/go-kratos/aegis/circuitbreaker/sre"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBreaker struct {
+	success int
+	failed  int
+}
+
+func (b *mockBreaker) Allow() error { return nil }
+func (b *mockBreaker) MarkSuccess() { b.success++ }
+func (b *mockBreaker) MarkFailed()  { b.failed++ }
+
+func TestLatencyThreshold(t *testing.T) {
+	cb := &mockBreaker{}
+	b := NewBreaker(cb, WithThreshold(100*time.Millisecond))
+	b.MarkDone(10*time.Millisecond, nil)
+	b.MarkDone(100*time.Millisecond, nil)
+	assert.Equal(t, 2, cb.success)
+	b.MarkDone(101*time.Millisecond, nil)
+	b.MarkDone(10*time.Millisecond, errors.New("failed"))
+	assert.Equal(t, 2, cb.failed)
+	// the underlying breaker is still usable directly
+	b.MarkSuccess()
+	b.MarkFailed()
+	assert.Equal(t, 3, cb.success)
+	assert.Equal(t, 3, cb.failed)
+}
+
+func TestLatencyTarget(t *testing.T) {
+	cb := &mockBreaker{}
+	b := NewBreaker(cb,
+		WithTarget(100*time.Millisecond),
+		WithPercentile(0.9),
+		WithWindow(time.Second),
+		WithBucket(10),
+	)
+	// a single slow call within the percentile target is tolerated
+	for i := 0; i < 99; i++ {
+		b.MarkDone(10*time.Millisecond, nil)
+	}
+	b.MarkDone(time.Second, nil)
+	assert.Equal(t, 100, cb.success)
+	assert.InEpsilon(t, 10*time.Millisecond, b.Percentile(), 0.1)
+
+	// wait for the cache to be expired
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		b.MarkDone(time.Second, nil)
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.InEpsilon(t, time.Second, b.Percentile(), 0.1)
+	b.MarkDone(time.Second, nil)
+	b.MarkDone(10*time.Millisecond, nil)
+	assert.Equal(t, 1, cb.failed)
+}
+
+func TestLatencyWithLatencyBreaker(t *testing.T) {
+	cb := classic.NewBreaker(classic.WithRequest(10))
+	b := NewBreaker(cb, WithThreshold(100*time.Millisecond))
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, b.Allow())
+		b.MarkDone(time.Second, nil)
+	}
+	assert.Equal(t, circuitbreaker.StateOpen, cb.State())
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+}
+
+func TestLatencyForward(t *testing.T) {
+	var states []circuitbreaker.State
+	inner := sre.NewBreaker(sre.OnStateChange(func(_ string, _, to circuitbreaker.State) {
+		states = append(states, to)
+	}))
+	g := circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return NewBreaker(inner)
+	})
+	assert.NoError(t, g.ForceOpen("a", 0))
+	b := g.GetCircuitBreaker("a").(*Breaker)
+	assert.Equal(t, circuitbreaker.OverrideForceOpen, b.Override())
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+	assert.Equal(t, []circuitbreaker.State{circuitbreaker.StateOpen}, states)
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+	b.Reset()
+	assert.Equal(t, circuitbreaker.OverrideNone, b.Override())
+	assert.Nil(t, b.Allow())
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+
+	// the override is not supported by the underlying breaker
+	g = circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return NewBreaker(&mockBreaker{})
+	})
+	assert.Equal(t, circuitbreaker.ErrOverrideNotSupported, g.ForceOpen("a", 0))
+	b = g.GetCircuitBreaker("a").(*Breaker)
+	b.ForceOpen(0)
+	assert.Equal(t, circuitbreaker.OverrideNone, b.Override())
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+}
@@ -0,0 +1,80 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrOverrideNotSupported is returned when the circuit breaker can not be overridden manually.
+var ErrOverrideNotSupported = errors.New("circuitbreaker: override not supported")
+
+// OverrideMode is the manual override mode of a circuit breaker.
+type OverrideMode int32
+
+const (
+	// OverrideNone the circuit breaker works as usual.
+	OverrideNone OverrideMode = iota
+	// OverrideForceOpen the circuit breaker is pinned open, all requests are rejected.
+	OverrideForceOpen
+	// OverrideForceClosed the circuit breaker is pinned closed, all requests are allowed.
+	OverrideForceClosed
+)
+
+// String returns the name of the override mode.
+func (m OverrideMode) String() string {
+	switch m {
+	case OverrideNone:
+		return "none"
+	case OverrideForceOpen:
+		return "force-open"
+	case OverrideForceClosed:
+		return "force-closed"
+	}
+	return "unknown"
+}
+
+// Overrider is a circuit breaker which can be overridden manually.
+type Overrider interface {
+	// ForceOpen pins the breaker open for d, zero means until Reset.
+	ForceOpen(d time.Duration)
+	// ForceClose pins the breaker closed for d, zero means until Reset.
+	ForceClose(d time.Duration)
+	// Reset removes the override.
+	Reset()
+	// Override returns the current override mode.
+	Override() OverrideMode
+}
+
+// Override holds a manual override which is optionally time-limited,
+// it is safe for concurrent use and the zero value is no override.
+type Override struct {
+	v atomic.Value
+}
+
+// override is the value of Override.
+type override struct {
+	mode     OverrideMode
+	deadline time.Time
+}
+
+// Set sets the override mode for d since now, zero d means no time limit.
+func (o *Override) Set(mode OverrideMode, now time.Time, d time.Duration) {
+	v := &override{mode: mode}
+	if d > 0 {
+		v.deadline = now.Add(d)
+	}
+	o.v.Store(v)
+}
+
+// Mode returns the override mode at the given time.
+func (o *Override) Mode(now time.Time) OverrideMode {
+	v, ok := o.v.Load().(*override)
+	if !ok {
+		return OverrideNone
+	}
+	if !v.deadline.IsZero() && !now.Before(v.deadline) {
+		return OverrideNone
+	}
+	return v.mode
+}
@@ -7,7 +7,8 @@ import (
 	"time"
 
 	"github.com/go-kratos/aegis/circuitbreaker"
-	"github.com/go-kratos/aegis/internal/window"
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/window"
 	"golang.org/x/exp/rand"
 )
 
@@ -18,23 +19,51 @@ const (
 	// StateOpen when circuit breaker open, request not allowed, after sleep
 	// some duration, allow one single request for testing the health, if ok
 	// then state reset to closed, if not continue the step.
-	StateOpen int32 = iota
+	StateOpen = int32(circuitbreaker.StateOpen)
 	// StateClosed when circuit breaker closed, request allowed, the breaker
 	// calc to succeed ratio, if request num greater request setting and
 	// ratio lower than the setting ratio, then reset state to open.
-	StateClosed
+	StateClosed = int32(circuitbreaker.StateClosed)
 )
 
 var (
-	_ circuitbreaker.CircuitBreaker = (*Breaker)(nil)
+	_ circuitbreaker.StateCircuitBreaker = (*Breaker)(nil)
+	_ circuitbreaker.Overrider           = (*Breaker)(nil)
+	_ circuitbreaker.RejectionMarker     = (*Breaker)(nil)
 )
 
 // options is a breaker options.
 type options struct {
+	name          string
+	onStateChange circuitbreaker.StateChangeFunc
+	clock         clock.Clock
+
 	success float64
 	request int64
 	bucket  int
 	window  time.Duration
+	seed    uint64
+}
+
+// WithName with the name of the breaker passed to the state change callback.
+func WithName(name string) Option {
+	return func(c *options) {
+		c.name = name
+	}
+}
+
+// OnStateChange with the callback called on every state transition.
+func OnStateChange(f circuitbreaker.StateChangeFunc) Option {
+	return func(c *options) {
+		c.onStateChange = f
+	}
+}
+
+// WithClock with the time source of the breaker, default is the wall clock.
+func WithClock(clk clock.Clock) Option {
+	return func(c *options) {
+		c.clock = clk
+	}
 }
 
 // WithSuccess with the K = 1 / Success value of sre breaker, default success is 0.5
@@ -67,6 +96,31 @@ func WithBucket(b int) Option {
 	}
 }
 
+// WithSeed with the seed of the random drops, default is seeded by the time.
+// A fixed seed makes the drops reproducible, e.g. in simulations.
+func WithSeed(seed uint64) Option {
+	return func(c *options) {
+		c.seed = seed
+	}
+}
+
+// Stat contains the metrics snapshot of sre breaker.
+type Stat struct {
+	// Accepts is the number of requests accepted by the backend within the window.
+	Accepts int64
+	// Requests is the number of requests attempted within the window.
+	Requests int64
+	// K is the multiplier of accepts, equals to 1 / success.
+	K float64
+	// DropRatio is the current probability of rejecting a request,
+	// it is 1 under OverrideForceOpen and 0 under OverrideForceClosed.
+	DropRatio float64
+	// State is the current state of the breaker.
+	State circuitbreaker.State
+	// Override is the current manual override mode of the breaker.
+	Override circuitbreaker.OverrideMode
+}
+
 // Breaker is a sre CircuitBreaker pattern.
 type Breaker struct {
 	stat window.RollingCounter
@@ -79,31 +133,45 @@ type Breaker struct {
 	k       float64
 	request int64
 
-	state int32
+	state    int32
+	override circuitbreaker.Override
+
+	name          string
+	onStateChange circuitbreaker.StateChangeFunc
+	clock         clock.Clock
 }
 
-// NewBreaker return a sreBreaker with options
+// NewBreaker return a sreBreaker with options.
+// The returned breaker is a *Breaker, its state is available through
+// circuitbreaker.StateCircuitBreaker, and its snapshot by asserting *Breaker.
 func NewBreaker(opts ...Option) circuitbreaker.CircuitBreaker {
 	opt := options{
 		success: 0.6,
 		request: 100,
 		bucket:  10,
 		window:  3 * time.Second,
+		seed:    uint64(time.Now().UnixNano()),
 	}
 	for _, o := range opts {
 		o(&opt)
 	}
+	opt.clock = clock.OrNew(opt.clock)
 	counterOpts := window.RollingCounterOpts{
 		Size:           opt.bucket,
 		BucketDuration: time.Duration(int64(opt.window) / int64(opt.bucket)),
+		Clock:          opt.clock,
 	}
 	stat := window.NewRollingCounter(counterOpts)
 	return &Breaker{
 		stat:    stat,
-		r:       rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+		r:       rand.New(rand.NewSource(opt.seed)),
 		request: opt.request,
 		k:       1 / opt.success,
 		state:   StateClosed,
+
+		name:          opt.name,
+		onStateChange: opt.onStateChange,
+		clock:         opt.clock,
 	}
 }
 
@@ -121,19 +189,57 @@ func (b *Breaker) summary() (success int64, total int64) {
 	return
 }
 
-// Allow request if error returns nil.
-func (b *Breaker) Allow() error {
-	// The number of requests accepted by the backend
-	accepts, total := b.summary()
+// dropRatio returns the client request rejection probability,
+// it returns false if the breaker should be closed.
+func (b *Breaker) dropRatio(accepts, total int64) (float64, bool) {
 	// The number of requests attempted by the application layer(at the client, on top of the adaptive throttling system)
 	requests := b.k * float64(accepts)
 	// check overflow requests = K * accepts
 	if total < b.request || float64(total) < requests {
-		atomic.CompareAndSwapInt32(&b.state, StateOpen, StateClosed)
+		return 0, false
+	}
+	return math.Max(0, (float64(total)-requests)/float64(total+1)), true
+}
+
+// Stat takes a snapshot of the sre breaker.
+func (b *Breaker) Stat() Stat {
+	accepts, total := b.summary()
+	override := b.Override()
+	var dr float64
+	switch override {
+	case circuitbreaker.OverrideForceOpen:
+		dr = 1
+	case circuitbreaker.OverrideNone:
+		dr, _ = b.dropRatio(accepts, total)
+	}
+	return Stat{
+		Accepts:   accepts,
+		Requests:  total,
+		K:         b.k,
+		DropRatio: dr,
+		State:     b.State(),
+		Override:  override,
+	}
+}
+
+// Allow request if error returns nil.
+func (b *Breaker) Allow() error {
+	switch b.Override() {
+	case circuitbreaker.OverrideForceOpen:
+		b.transition(StateClosed, StateOpen)
+		return circuitbreaker.ErrNotAllowed
+	case circuitbreaker.OverrideForceClosed:
+		b.transition(StateOpen, StateClosed)
 		return nil
 	}
-	atomic.CompareAndSwapInt32(&b.state, StateClosed, StateOpen)
-	dr := math.Max(0, (float64(total)-requests)/float64(total+1))
+	// The number of requests accepted by the backend
+	accepts, total := b.summary()
+	dr, open := b.dropRatio(accepts, total)
+	if !open {
+		b.transition(StateOpen, StateClosed)
+		return nil
+	}
+	b.transition(StateClosed, StateOpen)
 	drop := b.trueOnProba(dr)
 	if drop {
 		return circuitbreaker.ErrNotAllowed
@@ -141,6 +247,41 @@ func (b *Breaker) Allow() error {
 	return nil
 }
 
+// State returns the current state of the breaker.
+func (b *Breaker) State() circuitbreaker.State {
+	return circuitbreaker.State(atomic.LoadInt32(&b.state))
+}
+
+// transition switches the state with CAS, so the callback is called
+// only once per transition under concurrent requests.
+func (b *Breaker) transition(from, to int32) {
+	if atomic.CompareAndSwapInt32(&b.state, from, to) && b.onStateChange != nil {
+		b.onStateChange(b.name, circuitbreaker.State(from), circuitbreaker.State(to))
+	}
+}
+
+// ForceOpen pins the breaker open for d, zero means until Reset.
+func (b *Breaker) ForceOpen(d time.Duration) {
+	b.override.Set(circuitbreaker.OverrideForceOpen, b.clock.Now(), d)
+	b.transition(StateClosed, StateOpen)
+}
+
+// ForceClose pins the breaker closed for d, zero means until Reset.
+func (b *Breaker) ForceClose(d time.Duration) {
+	b.override.Set(circuitbreaker.OverrideForceClosed, b.clock.Now(), d)
+	b.transition(StateOpen, StateClosed)
+}
+
+// Reset removes the override, the state is recalculated on the next request.
+func (b *Breaker) Reset() {
+	b.override.Set(circuitbreaker.OverrideNone, b.clock.Now(), 0)
+}
+
+// Override returns the current override mode.
+func (b *Breaker) Override() circuitbreaker.OverrideMode {
+	return b.override.Mode(b.clock.Now())
+}
+
 // MarkSuccess mark request is success.
 func (b *Breaker) MarkSuccess() {
 	b.stat.Add(1)
@@ -153,6 +294,12 @@ func (b *Breaker) MarkFailed() {
 	b.stat.Add(0)
 }
 
+// MarkRejected mark request is rejected locally by Allow,
+// it is counted as a failed request.
+func (b *Breaker) MarkRejected() {
+	b.stat.Add(0)
+}
+
 func (b *Breaker) trueOnProba(proba float64) (truth bool) {
 	b.randLock.Lock()
 	truth = b.r.Float64() < proba
@@ -2,10 +2,14 @@ package sre
 
 import (
 	"math"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	"github.com/go-kratos/aegis/internal/window"
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/window"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/exp/rand"
 )
@@ -17,8 +21,9 @@ func getSREBreaker() *Breaker {
 	}
 	stat := window.NewRollingCounter(counterOpts)
 	return &Breaker{
-		stat: stat,
-		r:    rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+		stat:  stat,
+		r:     rand.New(rand.NewSource(uint64(time.Now().UnixNano()))),
+		clock: clock.New(),
 
 		request: 100,
 		k:       2,
@@ -148,6 +153,150 @@ func TestSRESummary(t *testing.T) {
 	})
 }
 
+func TestSREStateChange(t *testing.T) {
+	var opened, closed int64
+	b := NewBreaker(
+		WithName("test"),
+		WithWindow(time.Second),
+		OnStateChange(func(name string, from, to circuitbreaker.State) {
+			assert.Equal(t, "test", name)
+			switch to {
+			case circuitbreaker.StateOpen:
+				assert.Equal(t, circuitbreaker.StateClosed, from)
+				atomic.AddInt64(&opened, 1)
+			case circuitbreaker.StateClosed:
+				assert.Equal(t, circuitbreaker.StateOpen, from)
+				atomic.AddInt64(&closed, 1)
+			}
+		}),
+	).(*Breaker)
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	markFailed(b, 1000)
+
+	allowConcurrently := func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					_ = b.Allow()
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	allowConcurrently()
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+	assert.Equal(t, int64(1), atomic.LoadInt64(&opened))
+	assert.Equal(t, int64(0), atomic.LoadInt64(&closed))
+
+	time.Sleep(time.Second)
+	allowConcurrently()
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	assert.Equal(t, int64(1), atomic.LoadInt64(&opened))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&closed))
+}
+
+func TestSRENewBreaker(t *testing.T) {
+	b := NewBreaker()
+	sb, ok := b.(circuitbreaker.StateCircuitBreaker)
+	assert.True(t, ok)
+	assert.Equal(t, circuitbreaker.StateClosed, sb.State())
+	_, ok = b.(circuitbreaker.Overrider)
+	assert.True(t, ok)
+}
+
+func TestSREStat(t *testing.T) {
+	b := getSREBreaker()
+	stat := b.Stat()
+	assert.Equal(t, Stat{K: 2, State: circuitbreaker.StateClosed}, stat)
+
+	markSuccess(b, 100)
+	markFailed(b, 300)
+	_ = b.Allow()
+	stat = b.Stat()
+	assert.Equal(t, int64(100), stat.Accepts)
+	assert.Equal(t, int64(400), stat.Requests)
+	assert.Equal(t, float64(2), stat.K)
+	assert.InDelta(t, float64(200)/float64(401), stat.DropRatio, 1e-9)
+	assert.Equal(t, circuitbreaker.StateOpen, stat.State)
+}
+
+func TestSREMarkRejected(t *testing.T) {
+	b := getSREBreaker()
+	markSuccess(b, 100)
+	b.MarkRejected()
+	stat := b.Stat()
+	assert.Equal(t, int64(100), stat.Accepts)
+	assert.Equal(t, int64(101), stat.Requests)
+}
+
+func TestSREOverride(t *testing.T) {
+	b := getSREBreaker()
+	b.ForceOpen(0)
+	assert.Equal(t, circuitbreaker.OverrideForceOpen, b.Override())
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+	markSuccess(b, 1000)
+	assert.Equal(t, circuitbreaker.ErrNotAllowed, b.Allow())
+	stat := b.Stat()
+	assert.Equal(t, circuitbreaker.StateOpen, stat.State)
+	assert.Equal(t, circuitbreaker.OverrideForceOpen, stat.Override)
+	assert.Equal(t, 1.0, stat.DropRatio)
+
+	b.Reset()
+	assert.Equal(t, circuitbreaker.OverrideNone, b.Override())
+	assert.Nil(t, b.Allow())
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+
+	b = getSREBreaker()
+	markFailed(b, 10000000)
+	assert.Greater(t, b.Stat().DropRatio, 0.0)
+	b.ForceClose(100 * time.Millisecond)
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+	assert.Equal(t, 0.0, b.Stat().DropRatio)
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, b.Allow())
+	}
+	// the override is expired
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, circuitbreaker.OverrideNone, b.Override())
+	assert.NotNil(t, b.Allow())
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+}
+
+func TestSREWithClock(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	b := NewBreaker(WithClock(c), WithWindow(time.Minute)).(*Breaker)
+	markFailed(b, 1000)
+	assert.NotNil(t, b.Allow())
+	assert.Equal(t, circuitbreaker.StateOpen, b.State())
+	c.Advance(30 * time.Second)
+	assert.Equal(t, int64(1000), b.Stat().Requests)
+	c.Advance(30 * time.Second)
+	assert.Nil(t, b.Allow())
+	assert.Equal(t, circuitbreaker.StateClosed, b.State())
+
+	b.ForceOpen(time.Hour)
+	c.Advance(59 * time.Minute)
+	assert.Equal(t, circuitbreaker.OverrideForceOpen, b.Override())
+	c.Advance(time.Minute)
+	assert.Equal(t, circuitbreaker.OverrideNone, b.Override())
+}
+
+func TestSREWithSeed(t *testing.T) {
+	drops := func(seed uint64) []bool {
+		b := NewBreaker(WithSeed(seed)).(*Breaker)
+		var drops []bool
+		for i := 0; i < 100; i++ {
+			drops = append(drops, b.trueOnProba(0.5))
+		}
+		return drops
+	}
+	assert.Equal(t, drops(1), drops(1))
+	assert.NotEqual(t, drops(1), drops(2))
+}
+
 func TestTrueOnProba(t *testing.T) {
 	const proba = math.Pi / 10
 	const total = 100000
@@ -0,0 +1,70 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	_ Clock = (*realClock)(nil)
+	_ Clock = (*Fake)(nil)
+)
+
+// Clock tells the current time, it is used to replace time.Now in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// realClock is the wall clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// New returns the wall clock backed by time.Now.
+func New() Clock {
+	return realClock{}
+}
+
+// OrNew returns c, or the wall clock if c is nil.
+func OrNew(c Clock) Clock {
+	if c == nil {
+		return New()
+	}
+	return c
+}
+
+// Fake is a manual clock which only moves on Advance or Set,
+// it is used to simulate the passing of time deterministically.
+type Fake struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewFake returns a fake clock starting at now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the current time of the fake clock.
+func (f *Fake) Now() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.now
+}
+
+// Advance moves the fake clock forward by d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
+
+// Set sets the time of the fake clock.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	f.now = now
+	f.mu.Unlock()
+}
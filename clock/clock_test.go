@@ -0,0 +1,23 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Now()
+	c := New()
+	assert.False(t, c.Now().Before(start))
+	assert.Equal(t, c, OrNew(nil))
+
+	f := NewFake(start)
+	assert.Equal(t, Clock(f), OrNew(f))
+	assert.Equal(t, start, f.Now())
+	f.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), f.Now())
+	f.Set(start)
+	assert.Equal(t, start, f.Now())
+}
@@ -7,12 +7,14 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/go-kratos/aegis/sysstat"
 	pscpu "github.com/shirou/gopsutil/v3/cpu"
 )
 
 var _ CPU = (*cgroupCPU)(nil)
 
 type cgroupCPU struct {
+	reader    *sysstat.Reader
 	frequency uint64
 	quota     float64
 	cores     uint64
@@ -21,23 +23,19 @@ type cgroupCPU struct {
 	preTotal  uint64
 }
 
-func newCgroupCPU() (cpu *cgroupCPU, err error) {
-	cores, err := pscpu.Counts(true)
-	if err != nil || cores == 0 {
-		cores, err = logicalCores()
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	sets, err := cpuSets()
+func newCgroupCPU(reader *sysstat.Reader) (cpu *cgroupCPU, err error) {
+	stat, err := reader.CPU()
 	if err != nil {
 		return
 	}
-	quota := float64(len(sets))
-	limit, err := cpuLimits()
-	if err == nil && quota > limit {
-		quota = limit
+	cores, err := pscpu.Counts(true)
+	if err != nil || cores == 0 {
+		cores = len(stat.CPUs)
+	}
+	quota := float64(len(stat.CPUs))
+	// NOTE: the limit is zero if no cfs quota is set
+	if stat.Limit > 0 && quota > stat.Limit {
+		quota = stat.Limit
 	}
 
 	maxFreq := cpuMaxFreq()
@@ -46,26 +44,23 @@ func newCgroupCPU() (cpu *cgroupCPU, err error) {
 	if err != nil {
 		return
 	}
-	preTotal, err := totalCPUUsage()
-	if err != nil {
-		return
-	}
 	cpu = &cgroupCPU{
+		reader:    reader,
 		frequency: maxFreq,
 		quota:     quota,
 		cores:     uint64(cores),
 		preSystem: preSystem,
-		preTotal:  preTotal,
+		preTotal:  uint64(stat.Usage),
 	}
 	return
 }
 
 func (cpu *cgroupCPU) Usage() (u uint64, err error) {
 	var (
-		total  uint64
+		stat   *sysstat.CPUStat
 		system uint64
 	)
-	total, err = totalCPUUsage()
+	stat, err = cpu.reader.CPU()
 	if err != nil {
 		return
 	}
@@ -73,6 +68,7 @@ func (cpu *cgroupCPU) Usage() (u uint64, err error) {
 	if err != nil {
 		return
 	}
+	total := uint64(stat.Usage)
 	if system != cpu.preSystem {
 		u = uint64(float64((total-cpu.preTotal)*cpu.cores*1e3) / (float64(system-cpu.preSystem) * cpu.quota))
 	}
@@ -90,9 +86,6 @@ func (cpu *cgroupCPU) Info() Info {
 
 const nanoSecondsPerSecond = 1e9
 
-// ErrNoCFSLimit is no quota limit
-var ErrNoCFSLimit = errors.New("no quota limit")
-
 var clockTicksPerSecond = uint64(getClockTicks())
 
 // systemCPUUsage returns the host system's cpu usage in
@@ -142,38 +135,6 @@ func systemCPUUsage() (usage uint64, err error) {
 	}
 }
 
-func totalCPUUsage() (usage uint64, err error) {
-	var cg cgroup
-	if cg, err = newCGroup(); err != nil {
-		return
-	}
-	return cg.CPUAcctUsageNs()
-}
-
-func logicalCores() (cores int, err error) {
-	var cg cgroup
-	if cg, err = newCGroup(); err != nil {
-		return
-	}
-	return cg.LogicalCores()
-}
-
-func cpuSets() (sets []uint64, err error) {
-	var cg cgroup
-	if cg, err = newCGroup(); err != nil {
-		return
-	}
-	return cg.CPUSetCPUs()
-}
-
-func cpuLimits() (limits float64, err error) {
-	var cg cgroup
-	if cg, err = newCGroup(); err != nil {
-		return
-	}
-	return cg.CPULimits()
-}
-
 func cpuFreq() uint64 {
 	lines, err := readLines("/proc/cpuinfo")
 	if err != nil {
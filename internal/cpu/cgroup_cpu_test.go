@@ -0,0 +1,35 @@
+package cpu
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-kratos/aegis/sysstat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCgroupCPUQuota(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sys/fs/cgroup/cgroup.controllers":    &fstest.MapFile{Data: []byte("cpuset cpu\n")},
+		"sys/fs/cgroup/cpu.stat":              &fstest.MapFile{Data: []byte("usage_usec 2000\n")},
+		"sys/fs/cgroup/cpuset.cpus.effective": &fstest.MapFile{Data: []byte("0-3\n")},
+	}
+	tests := []struct {
+		max   string
+		quota float64
+	}{
+		{"150000 100000\n", 1.5},
+		{"800000 100000\n", 4},
+		{"max 100000\n", 4},
+	}
+	for _, tt := range tests {
+		fsys["sys/fs/cgroup/cpu.max"] = &fstest.MapFile{Data: []byte(tt.max)}
+		cpu, err := newCgroupCPU(sysstat.New(fsys))
+		if err != nil {
+			// /proc/stat is not available
+			t.Skip(err)
+		}
+		assert.Equal(t, tt.quota, cpu.Info().Quota)
+		assert.Equal(t, uint64(2000000), cpu.preTotal)
+	}
+}
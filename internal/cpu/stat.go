@@ -2,17 +2,9 @@ package cpu
 
 import (
 	"fmt"
-	"sync/atomic"
 	"time"
-)
-
-const (
-	interval time.Duration = time.Millisecond * 500
-)
 
-var (
-	stats CPU
-	usage uint64
+	"github.com/go-kratos/aegis/sysstat"
 )
 
 // CPU is cpu stat usage.
@@ -21,34 +13,18 @@ type CPU interface {
 	Info() Info
 }
 
-func init() {
-	var (
-		err error
-	)
-	stats, err = newCgroupCPU()
-	if err != nil {
-		// fmt.Printf("cgroup cpu init failed(%v),switch to psutil cpu\n", err)
-		stats, err = newPsutilCPU(interval)
-		if err != nil {
-			panic(fmt.Sprintf("cgroup cpu init failed!err:=%v", err))
-		}
+// New returns the cpu stat of the process, it reads the cgroup and falls back
+// to psutil, interval is the duration psutil measures the usage over.
+func New(interval time.Duration) (CPU, error) {
+	stats, err := newCgroupCPU(sysstat.Default())
+	if err == nil {
+		return stats, nil
 	}
-	go func() {
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
-		for {
-			<-ticker.C
-			u, err := stats.Usage()
-			if err == nil && u != 0 {
-				atomic.StoreUint64(&usage, u)
-			}
-		}
-	}()
-}
-
-// Stat cpu stat.
-type Stat struct {
-	Usage uint64 // cpu use ratio.
+	ps, psErr := newPsutilCPU(interval)
+	if psErr != nil {
+		return nil, fmt.Errorf("cpu: cgroup cpu init failed(%v), psutil cpu init failed(%v)", err, psErr)
+	}
+	return ps, nil
 }
 
 // Info cpu info.
@@ -56,13 +32,3 @@ type Info struct {
 	Frequency uint64
 	Quota     float64
 }
-
-// ReadStat read cpu stat.
-func ReadStat(stat *Stat) {
-	stat.Usage = atomic.LoadUint64(&usage)
-}
-
-// GetInfo get cpu info.
-func GetInfo() Info {
-	return stats.Info()
-}
@@ -8,14 +8,18 @@ import (
 )
 
 func TestStat(t *testing.T) {
-	time.Sleep(time.Second * 3)
+	stats, err := New(time.Millisecond * 500)
+	assert.Nil(t, err)
 
-	var s Stat
-	var i Info
-	ReadStat(&s)
-	i = GetInfo()
+	var u uint64
+	for i := 0; i < 6 && u == 0; i++ {
+		time.Sleep(time.Millisecond * 500)
+		u, err = stats.Usage()
+		assert.Nil(t, err)
+	}
+	i := stats.Info()
 
-	assert.NotZero(t, s.Usage)
+	assert.NotZero(t, u)
 	assert.NotZero(t, i.Frequency)
 	assert.NotZero(t, i.Quota)
 }
@@ -0,0 +1,272 @@
+// Package lru provides a cache of string keys bounded by capacity and idle expiry.
+package lru
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+)
+
+// Reason is the reason why an entry is evicted.
+type Reason int
+
+const (
+	// ReasonCapacity the entry is evicted since the capacity is exceeded.
+	ReasonCapacity Reason = iota
+	// ReasonExpired the entry is evicted since it is idle for longer than the expires.
+	ReasonExpired
+)
+
+// Options is the options of the cache.
+type Options[V any] struct {
+	// Capacity is the maximum number of entries, zero means no limit.
+	Capacity int
+	// Expires is the idle duration after which an entry is evicted, zero means never expire.
+	Expires time.Duration
+	// Clock is the time source, default is the wall clock.
+	Clock clock.Clock
+	// Pinned reports whether the value is in use, pinned values are never
+	// evicted and their idle duration starts once they are unpinned.
+	Pinned func(value V) bool
+	// OnEvict is called with the cache locked once an entry is evicted.
+	OnEvict func(key string, value V, reason Reason)
+}
+
+// Cache is a cache of string keys bounded by capacity and idle expiry.
+//
+// The lookups of the existing keys are lock-free, only the insertions and the
+// evictions take the lock. Instead of reordering a list on every lookup, the
+// accessed entries are marked and moved to the front once they reach the
+// back of the queue, like the second chance of the CLOCK algorithm.
+type Cache[V any] struct {
+	entries sync.Map // key -> *entry[V]
+
+	mu sync.Mutex
+	// queue orders the entries by insertion, the marked entries are
+	// moved to the front once they reach the back
+	queue *list.List
+	// swept is the unix nano of the last sweep of the expired entries
+	swept int64
+
+	opts Options[V]
+}
+
+// entry is an entry of the cache.
+type entry[V any] struct {
+	// access is the unix nano of the last access, it is the first field
+	// to be 64-bit aligned for the atomic operations.
+	access int64
+	// marked reports whether the entry is accessed since it was last queued
+	marked int32
+
+	key   string
+	value V
+	// elem is guarded by the lock of the cache
+	elem *list.Element
+}
+
+// New returns a cache with options.
+func New[V any](opts Options[V]) *Cache[V] {
+	opts.Clock = clock.OrNew(opts.Clock)
+	return &Cache[V]{
+		queue: list.New(),
+		opts:  opts,
+	}
+}
+
+// Get returns the value of the given key.
+func (c *Cache[V]) Get(key string) (value V, ok bool) {
+	if e := c.load(key, c.now()); e != nil {
+		return e.value, true
+	}
+	return value, false
+}
+
+// GetOrCreate returns the value of the given key, the value is created by
+// create if the key does not exist. create is called without the lock held,
+// its result is dropped if the key is created concurrently.
+func (c *Cache[V]) GetOrCreate(key string, create func() V) V {
+	now := c.now()
+	if e := c.load(key, now); e != nil {
+		return e.value
+	}
+	value := create()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.entries.Load(key); ok {
+		e := v.(*entry[V])
+		if !c.expired(e, now) {
+			c.touch(e, now)
+			return e.value
+		}
+		c.evict(e, ReasonExpired)
+	}
+	e := &entry[V]{access: now, key: key, value: value}
+	e.elem = c.queue.PushFront(e)
+	c.entries.Store(key, e)
+	c.sweep(now)
+	c.shrink(e, now)
+	return value
+}
+
+// Remove removes the given key, it returns false if the key does not exist.
+func (c *Cache[V]) Remove(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.entries.Load(key)
+	if ok {
+		c.remove(v.(*entry[V]))
+	}
+	return ok
+}
+
+// Range calls f sequentially for each key and value in the cache, from the
+// most to the least recently used. If f returns false, range stops the iteration.
+// f is called without the lock held, so it is able to modify the cache.
+func (c *Cache[V]) Range(f func(key string, value V) bool) {
+	now := c.now()
+	c.mu.Lock()
+	c.expire(now)
+	entries := make([]*entry[V], 0, c.queue.Len())
+	for elem := c.queue.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, elem.Value.(*entry[V]))
+	}
+	c.mu.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return atomic.LoadInt64(&entries[i].access) > atomic.LoadInt64(&entries[j].access)
+	})
+	for _, e := range entries {
+		if !f(e.key, e.value) {
+			return
+		}
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[V]) Len() int {
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(now)
+	return c.queue.Len()
+}
+
+// EvictExpired evicts the expired entries.
+func (c *Cache[V]) EvictExpired() {
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(now)
+}
+
+func (c *Cache[V]) now() int64 {
+	return c.opts.Clock.Now().UnixNano()
+}
+
+// load returns the entry of the given key if it is not expired.
+func (c *Cache[V]) load(key string, now int64) *entry[V] {
+	v, ok := c.entries.Load(key)
+	if !ok {
+		return nil
+	}
+	e := v.(*entry[V])
+	if c.expired(e, now) {
+		return nil
+	}
+	c.touch(e, now)
+	return e
+}
+
+func (c *Cache[V]) touch(e *entry[V], now int64) {
+	if atomic.LoadInt64(&e.access) != now {
+		atomic.StoreInt64(&e.access, now)
+	}
+	if atomic.LoadInt32(&e.marked) == 0 {
+		atomic.StoreInt32(&e.marked, 1)
+	}
+}
+
+func (c *Cache[V]) pinned(e *entry[V]) bool {
+	return c.opts.Pinned != nil && c.opts.Pinned(e.value)
+}
+
+func (c *Cache[V]) expired(e *entry[V], now int64) bool {
+	if c.opts.Expires <= 0 || now-atomic.LoadInt64(&e.access) <= int64(c.opts.Expires) {
+		return false
+	}
+	return !c.pinned(e)
+}
+
+// sweep evicts the expired entries at most once per expires,
+// it must be called with the lock held.
+func (c *Cache[V]) sweep(now int64) {
+	if c.opts.Expires <= 0 || now-c.swept < int64(c.opts.Expires) {
+		return
+	}
+	c.expire(now)
+}
+
+// expire must be called with the lock held.
+func (c *Cache[V]) expire(now int64) {
+	if c.opts.Expires <= 0 {
+		return
+	}
+	c.swept = now
+	for elem := c.queue.Front(); elem != nil; {
+		e := elem.Value.(*entry[V])
+		elem = elem.Next()
+		if now-atomic.LoadInt64(&e.access) <= int64(c.opts.Expires) {
+			continue
+		}
+		if c.pinned(e) {
+			atomic.StoreInt64(&e.access, now)
+			continue
+		}
+		c.evict(e, ReasonExpired)
+	}
+}
+
+// shrink evicts the least recently used entries until the capacity is satisfied.
+// The marked entries are unmarked and moved to the front instead, and so are
+// the pinned entries, so every step costs O(1). The new entry is never evicted, so the cache
+// may exceed the capacity if all the other entries are pinned.
+// It must be called with the lock held.
+func (c *Cache[V]) shrink(added *entry[V], now int64) {
+	if c.opts.Capacity <= 0 {
+		return
+	}
+	// every entry is moved at most twice before the unmarked ones are evicted
+	for n := 2 * c.queue.Len(); n > 0 && c.queue.Len() > c.opts.Capacity; n-- {
+		elem := c.queue.Back()
+		e := elem.Value.(*entry[V])
+		switch {
+		case e == added:
+			c.queue.MoveToFront(elem)
+		case c.pinned(e):
+			atomic.StoreInt64(&e.access, now)
+			c.queue.MoveToFront(elem)
+		case atomic.SwapInt32(&e.marked, 0) == 1:
+			c.queue.MoveToFront(elem)
+		default:
+			c.evict(e, ReasonCapacity)
+		}
+	}
+}
+
+// evict must be called with the lock held.
+func (c *Cache[V]) evict(e *entry[V], reason Reason) {
+	c.remove(e)
+	if c.opts.OnEvict != nil {
+		c.opts.OnEvict(e.key, e.value, reason)
+	}
+}
+
+// remove must be called with the lock held.
+func (c *Cache[V]) remove(e *entry[V]) {
+	c.queue.Remove(e.elem)
+	c.entries.Delete(e.key)
+}
@@ -0,0 +1,174 @@
+package lru
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func keys(c *Cache[int]) []string {
+	var keys []string
+	c.Range(func(key string, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func TestCacheCapacity(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	var evicted []string
+	c := New(Options[int]{
+		Capacity: 2,
+		Clock:    clk,
+		OnEvict: func(key string, _ int, reason Reason) {
+			assert.Equal(t, ReasonCapacity, reason)
+			evicted = append(evicted, key)
+		},
+	})
+	assert.Equal(t, 1, c.GetOrCreate("a", func() int { return 1 }))
+	clk.Advance(time.Second)
+	c.GetOrCreate("b", func() int { return 2 })
+	clk.Advance(time.Second)
+	// a becomes the most recently used
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	clk.Advance(time.Second)
+	c.GetOrCreate("c", func() int { return 3 })
+	assert.Equal(t, []string{"b"}, evicted)
+	assert.Equal(t, []string{"c", "a"}, keys(c))
+
+	// a is moved to the front by its second chance, so c is evicted
+	// before it although c is used more recently
+	clk.Advance(time.Second)
+	c.GetOrCreate("d", func() int { return 4 })
+	assert.Equal(t, []string{"b", "c"}, evicted)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestCacheExpires(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	var expired []string
+	c := New(Options[int]{
+		Expires: time.Minute,
+		Clock:   clk,
+		OnEvict: func(key string, _ int, reason Reason) {
+			assert.Equal(t, ReasonExpired, reason)
+			expired = append(expired, key)
+		},
+	})
+	c.GetOrCreate("a", func() int { return 1 })
+	c.GetOrCreate("b", func() int { return 2 })
+	clk.Advance(40 * time.Second)
+	c.Get("b")
+	clk.Advance(40 * time.Second)
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, []string{"a"}, expired)
+
+	assert.Equal(t, 2, c.GetOrCreate("b", func() int { return 3 }))
+	// the expired entry is replaced
+	clk.Advance(2 * time.Minute)
+	assert.Equal(t, 4, c.GetOrCreate("b", func() int { return 4 }))
+	c.EvictExpired()
+	assert.Equal(t, []string{"a", "b"}, expired)
+}
+
+func TestCachePinned(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	var pinned int32 = 1
+	c := New(Options[*int32]{
+		Capacity: 1,
+		Expires:  time.Minute,
+		Clock:    clk,
+		Pinned:   func(v *int32) bool { return atomic.LoadInt32(v) > 0 },
+	})
+	c.GetOrCreate("a", func() *int32 { return &pinned })
+	c.GetOrCreate("b", func() *int32 { return new(int32) })
+	// the pinned entry is not evicted by the capacity
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.True(t, c.Remove("b"))
+
+	clk.Advance(2 * time.Minute)
+	c.EvictExpired()
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+
+	// the idle duration starts once unpinned
+	atomic.StoreInt32(&pinned, 0)
+	clk.Advance(2 * time.Minute)
+	c.EvictExpired()
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+}
+
+func TestCacheRemove(t *testing.T) {
+	c := New(Options[int]{})
+	c.GetOrCreate("a", func() int { return 1 })
+	c.GetOrCreate("b", func() int { return 2 })
+	assert.True(t, c.Remove("a"))
+	assert.False(t, c.Remove("a"))
+	// remove keys while ranging
+	c.Range(func(key string, _ int) bool {
+		c.Remove(key)
+		return true
+	})
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	var created int32
+	c := New(Options[int]{Capacity: 10})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.GetOrCreate(string(rune('a'+j%20)), func() int {
+					atomic.AddInt32(&created, 1)
+					return j
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 10, c.Len())
+	assert.True(t, atomic.LoadInt32(&created) >= 20)
+}
+
+func BenchmarkCacheGet(b *testing.B) {
+	c := New(Options[int]{Capacity: 1000, Expires: time.Minute})
+	for i := 0; i < 1000; i++ {
+		c.GetOrCreate(string(rune(i)), func() int { return i })
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Get(string(rune(i % 1000)))
+			i++
+		}
+	})
+}
+
+func BenchmarkCacheInsert(b *testing.B) {
+	c := New(Options[int]{Capacity: 32000})
+	for i := 0; i < 32000; i++ {
+		key := strconv.Itoa(i)
+		c.GetOrCreate(key, func() int { return i })
+		c.Get(key)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.GetOrCreate(strconv.Itoa(32000+i), func() int { return i })
+	}
+}
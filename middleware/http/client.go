@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+)
+
+// TransportOption is client transport option function.
+type TransportOption func(*transportOptions)
+
+// transportOptions is client transport options.
+type transportOptions struct {
+	base        http.RoundTripper
+	key         func(r *http.Request) string
+	statusError func(code int) error
+}
+
+// WithBase with the underlying round tripper, default is http.DefaultTransport.
+func WithBase(rt http.RoundTripper) TransportOption {
+	return func(o *transportOptions) {
+		o.base = rt
+	}
+}
+
+// WithKey with the function which returns the breaker key of the request,
+// default is the host of the request URL.
+func WithKey(f func(r *http.Request) string) TransportOption {
+	return func(o *transportOptions) {
+		o.key = f
+	}
+}
+
+// WithResponseError with the classifier which maps the response status code
+// to the error marked to the breaker, default is DefaultStatusError.
+func WithResponseError(f func(code int) error) TransportOption {
+	return func(o *transportOptions) {
+		o.statusError = f
+	}
+}
+
+func hostKey(r *http.Request) string {
+	return r.URL.Host
+}
+
+// Transport is a client round tripper which applies a circuit breaker
+// per host of the requests.
+type Transport struct {
+	group *circuitbreaker.Group
+	opts  transportOptions
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// NewTransport returns a client round tripper of the circuit breaker group.
+func NewTransport(group *circuitbreaker.Group, opts ...TransportOption) *Transport {
+	o := transportOptions{
+		base:        http.DefaultTransport,
+		key:         hostKey,
+		statusError: DefaultStatusError,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Transport{group: group, opts: o}
+}
+
+// RoundTrip implements http.RoundTripper, it returns circuitbreaker.ErrNotAllowed
+// if the breaker of the request rejects it, the request body is closed as well.
+// The request is called by circuitbreaker.Do, so the transport errors including
+// timeouts and the failure status codes are marked as failed, and the requests
+// canceled by the caller are not marked.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	cb := t.group.GetCircuitBreaker(t.opts.key(r))
+	resp, err := circuitbreaker.DoWithFallback(r.Context(), cb, func(context.Context) (*http.Response, error) {
+		resp, err := t.opts.base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		if err = t.opts.statusError(resp.StatusCode); err != nil {
+			return resp, &responseError{err: err}
+		}
+		return resp, nil
+	}, func(_ context.Context, err error) (*http.Response, error) {
+		// RoundTrip must always close the body, including on errors
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, err
+	})
+	var re *responseError
+	if errors.As(err, &re) {
+		// the response is returned to the caller as is
+		return resp, nil
+	}
+	return resp, err
+}
+
+// responseError is the error of a response with a failure status code,
+// it is marked to the breaker but not returned by RoundTrip.
+type responseError struct {
+	err error
+}
+
+func (e *responseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *responseError) Unwrap() error {
+	return e.err
+}
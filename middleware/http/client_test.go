@@ -0,0 +1,139 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/circuitbreaker/consecutive"
+	"github.com/go-kratos/aegis/circuitbreaker/sre"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport(t *testing.T) {
+	code := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(code)
+	}))
+	defer srv.Close()
+
+	group := circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return consecutive.NewBreaker(consecutive.WithFailures(2))
+	})
+	client := &http.Client{Transport: NewTransport(group)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, code, resp.StatusCode)
+	}
+	_, err := client.Get(srv.URL)
+	assert.ErrorIs(t, err, circuitbreaker.ErrNotAllowed)
+	assert.Equal(t, 1, group.Len())
+
+	// the breakers are per host
+	code = http.StatusNotFound
+	srv2 := httptest.NewServer(srv.Config.Handler)
+	defer srv2.Close()
+	resp, err := client.Get(srv2.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 2, group.Len())
+}
+
+func TestTransportTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+		}
+	}))
+	defer srv.Close()
+
+	group := circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return consecutive.NewBreaker(consecutive.WithFailures(1))
+	})
+	client := &http.Client{Transport: NewTransport(group)}
+
+	// the request canceled by the caller is not a failure
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	_, err := client.Do(req)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = client.Get(srv.URL)
+	assert.ErrorIs(t, err, circuitbreaker.ErrNotAllowed)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTransportOptions(t *testing.T) {
+	var keys []string
+	group := circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return consecutive.NewBreaker(consecutive.WithFailures(1))
+	})
+	rt := NewTransport(group,
+		WithBase(roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusTooManyRequests, Body: http.NoBody}, nil
+		})),
+		WithKey(func(r *http.Request) string {
+			keys = append(keys, r.URL.Path)
+			return r.URL.Path
+		}),
+		WithResponseError(func(code int) error {
+			if code == http.StatusTooManyRequests {
+				return errors.New("throttled")
+			}
+			return nil
+		}),
+	)
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/a", nil)
+	_, err := rt.RoundTrip(req)
+	assert.NoError(t, err)
+	_, err = rt.RoundTrip(req)
+	assert.ErrorIs(t, err, circuitbreaker.ErrNotAllowed)
+	assert.Equal(t, []string{"/a", "/a"}, keys)
+}
+
+type closeBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestTransportRejectedBody(t *testing.T) {
+	group := circuitbreaker.NewGroup(func() circuitbreaker.CircuitBreaker {
+		return sre.NewBreaker()
+	})
+	assert.NoError(t, group.ForceOpen("example.com", 0))
+	rt := NewTransport(group, WithBase(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Fatal("the rejected request is sent")
+		return nil, nil
+	})))
+	body := &closeBody{Reader: strings.NewReader("body")}
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/a", body)
+	_, err := rt.RoundTrip(req)
+	assert.ErrorIs(t, err, circuitbreaker.ErrNotAllowed)
+	assert.True(t, body.closed)
+}
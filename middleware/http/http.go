@@ -0,0 +1,26 @@
+// Package http provides net/http middlewares of the rate limiters
+// and the circuit breakers.
+package http
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StatusError is the error of a response with a failure status code.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http: status %d %s", e.Code, http.StatusText(e.Code))
+}
+
+// DefaultStatusError is the default classifier of the response status,
+// 5xx status codes are errors.
+func DefaultStatusError(code int) error {
+	if code >= http.StatusInternalServerError {
+		return &StatusError{Code: code}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+// ServerOption is server middleware option function.
+type ServerOption func(*serverOptions)
+
+// serverOptions is server middleware options.
+type serverOptions struct {
+	retryAfter  time.Duration
+	statusError func(code int) error
+	rejected    http.Handler
+}
+
+// WithRetryAfter with the duration of the Retry-After header of the rejected
+// requests, it is rounded up to seconds, default is 1 second.
+// Zero means no Retry-After header.
+func WithRetryAfter(d time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		o.retryAfter = d
+	}
+}
+
+// WithStatusError with the classifier which maps the response status code
+// to the error reported to the limiter, default is DefaultStatusError.
+func WithStatusError(f func(code int) error) ServerOption {
+	return func(o *serverOptions) {
+		o.statusError = f
+	}
+}
+
+// WithRejected with the handler serving the rejected requests,
+// default replies 429 Too Many Requests.
+// The Retry-After header is set before the handler is called.
+func WithRejected(h http.Handler) ServerOption {
+	return func(o *serverOptions) {
+		o.rejected = h
+	}
+}
+
+func tooManyRequests(w http.ResponseWriter, _ *http.Request) {
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
+
+// Limiter returns a server middleware which admits the requests by the limiter.
+// The status code of the response, or the error of the request context,
+// is reported to the limiter once the request is done.
+func Limiter(l ratelimit.Limiter, opts ...ServerOption) func(http.Handler) http.Handler {
+	o := serverOptions{
+		retryAfter:  time.Second,
+		statusError: DefaultStatusError,
+		rejected:    http.HandlerFunc(tooManyRequests),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done, err := l.Allow()
+			if err != nil {
+				if o.retryAfter > 0 {
+					w.Header().Set("Retry-After", retryAfter(o.retryAfter))
+				}
+				o.rejected.ServeHTTP(w, r)
+				return
+			}
+			rw := &responseWriter{ResponseWriter: w, code: http.StatusOK}
+			defer func() {
+				if p := recover(); p != nil {
+					done(ratelimit.DoneInfo{Err: fmt.Errorf("http: panic: %v", p)})
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+			err = r.Context().Err()
+			if err == nil {
+				err = o.statusError(rw.code)
+			}
+			done(ratelimit.DoneInfo{Err: err})
+		})
+	}
+}
+
+// retryAfter returns the Retry-After header value of d in seconds.
+func retryAfter(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
+
+// responseWriter records the status code of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	// NOTE: 1xx informational responses are followed by the final response
+	if !w.wroteHeader && code >= http.StatusOK {
+		w.code = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the underlying writer does.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the underlying writer does.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http: response writer does not implement http.Hijacker")
+	}
+	w.wroteHeader = true
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLimiter struct {
+	allow error
+	infos []ratelimit.DoneInfo
+}
+
+func (l *mockLimiter) Allow() (ratelimit.DoneFunc, error) {
+	if l.allow != nil {
+		return nil, l.allow
+	}
+	return func(info ratelimit.DoneInfo) {
+		l.infos = append(l.infos, info)
+	}, nil
+}
+
+func statusHandler(code int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if code != http.StatusOK {
+			w.WriteHeader(code)
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+func TestLimiterRejected(t *testing.T) {
+	l := &mockLimiter{allow: ratelimit.ErrLimitExceed}
+	h := Limiter(l, WithRetryAfter(1500*time.Millisecond))(statusHandler(http.StatusOK))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	h = Limiter(l, WithRetryAfter(0), WithRejected(statusHandler(http.StatusServiceUnavailable)))(statusHandler(http.StatusOK))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+}
+
+func TestLimiterDoneInfo(t *testing.T) {
+	l := &mockLimiter{}
+	for _, code := range []int{http.StatusOK, http.StatusNotFound, http.StatusBadGateway} {
+		w := httptest.NewRecorder()
+		Limiter(l)(statusHandler(code)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, code, w.Code)
+	}
+	assert.Len(t, l.infos, 3)
+	assert.NoError(t, l.infos[0].Err)
+	assert.NoError(t, l.infos[1].Err)
+	var serr *StatusError
+	assert.True(t, errors.As(l.infos[2].Err, &serr))
+	assert.Equal(t, http.StatusBadGateway, serr.Code)
+
+	// the request canceled by the client
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	Limiter(l)(statusHandler(http.StatusOK)).ServeHTTP(httptest.NewRecorder(), r)
+	assert.ErrorIs(t, l.infos[3].Err, context.Canceled)
+}
+
+func TestLimiterPanic(t *testing.T) {
+	l := &mockLimiter{}
+	h := Limiter(l)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	assert.Panics(t, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Len(t, l.infos, 1)
+	assert.Error(t, l.infos[0].Err)
+}
+
+func TestLimiterHijack(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		defer conn.Close()
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		_ = buf.Flush()
+	})
+	srv := httptest.NewServer(Limiter(&mockLimiter{})(handler))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "hijacked", string(body))
+
+	// the recorder does not implement http.Hijacker
+	l := &mockLimiter{}
+	w := httptest.NewRecorder()
+	Limiter(l)(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+	assert.Len(t, l.infos, 1)
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, "1", retryAfter(time.Millisecond))
+	assert.Equal(t, "1", retryAfter(time.Second))
+	assert.Equal(t, "3", retryAfter(2*time.Second+time.Nanosecond))
+}
@@ -0,0 +1,125 @@
+package adaptive
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+var _ ratelimit.Limiter = (*Limiter)(nil)
+
+// Algorithm is a concurrency limit algorithm which learns from the latency samples.
+// The methods are called with the lock of the limiter held, so the algorithms
+// need not to be safe for concurrent use, and must not be shared by limiters.
+type Algorithm interface {
+	// Limit returns the current concurrency limit.
+	Limit() int
+	// Update learns from a sample, inflight is the number of requests in flight
+	// when the sampled request started, dropped reports whether the request
+	// was dropped or timed out, which is a strong signal of overload.
+	Update(rtt time.Duration, inflight int, dropped bool)
+}
+
+// Option is a function that configures the Limiter.
+type Option func(*options)
+
+// options of adaptive limiter.
+type options struct {
+	isDropped func(err error) bool
+	clock     clock.Clock
+}
+
+// WithIsDropped with the classifier of the errors reported by DoneInfo,
+// by default any error is a dropped request. The requests canceled by the
+// client (context.Canceled) are never classified, they are not sampled since
+// their latency is cut short by the client rather than the backend.
+func WithIsDropped(f func(err error) bool) Option {
+	return func(o *options) {
+		o.isDropped = f
+	}
+}
+
+// WithClock with the time source to measure the latency, default is the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// Stat is the statistics of the adaptive limiter.
+type Stat struct {
+	Limit    int
+	InFlight int
+}
+
+// Limiter is an adaptive concurrency limiter, it rejects the requests once the
+// number of requests in flight reaches the limit, which is learned by the
+// algorithm from the latency and errors reported by the done functions.
+// Unlike bbr, it does not depend on the CPU usage.
+//
+// See https://github.com/Netflix/concurrency-limits.
+type Limiter struct {
+	mu       sync.Mutex
+	inflight int
+
+	alg  Algorithm
+	opts options
+}
+
+// NewLimiter returns an adaptive limiter with the algorithm.
+func NewLimiter(alg Algorithm, opts ...Option) *Limiter {
+	opt := options{
+		isDropped: func(err error) bool {
+			return err != nil
+		},
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	opt.clock = clock.OrNew(opt.clock)
+	return &Limiter{
+		alg:  alg,
+		opts: opt,
+	}
+}
+
+// Allow checks whether the number of requests in flight is within the limit.
+func (l *Limiter) Allow() (ratelimit.DoneFunc, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= l.alg.Limit() {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	l.inflight++
+	inflight := l.inflight
+	start := l.opts.clock.Now()
+	var finished int32
+	return func(info ratelimit.DoneInfo) {
+		if !atomic.CompareAndSwapInt32(&finished, 0, 1) {
+			return
+		}
+		rtt := l.opts.clock.Now().Sub(start)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.inflight--
+		if errors.Is(info.Err, context.Canceled) {
+			return
+		}
+		l.alg.Update(rtt, inflight, l.opts.isDropped(info.Err))
+	}, nil
+}
+
+// Stat returns the statistics of the limiter.
+func (l *Limiter) Stat() Stat {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Stat{
+		Limit:    l.alg.Limit(),
+		InFlight: l.inflight,
+	}
+}
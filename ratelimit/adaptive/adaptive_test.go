@@ -0,0 +1,120 @@
+package adaptive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAlgorithm struct {
+	limit    int
+	rtt      time.Duration
+	inflight int
+	dropped  bool
+}
+
+func (m *mockAlgorithm) Limit() int { return m.limit }
+
+func (m *mockAlgorithm) Update(rtt time.Duration, inflight int, dropped bool) {
+	m.rtt, m.inflight, m.dropped = rtt, inflight, dropped
+}
+
+func TestLimiter(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	alg := &mockAlgorithm{limit: 2}
+	l := NewLimiter(alg, WithClock(clk))
+	done1, err := l.Allow()
+	assert.Nil(t, err)
+	done2, err := l.Allow()
+	assert.Nil(t, err)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+	assert.Equal(t, Stat{Limit: 2, InFlight: 2}, l.Stat())
+
+	clk.Advance(10 * time.Millisecond)
+	done2(ratelimit.DoneInfo{Err: errors.New("timeout")})
+	assert.Equal(t, 10*time.Millisecond, alg.rtt)
+	assert.Equal(t, 2, alg.inflight)
+	assert.True(t, alg.dropped)
+
+	done1(ratelimit.DoneInfo{})
+	assert.Equal(t, 1, alg.inflight)
+	assert.False(t, alg.dropped)
+	// calling done twice is a no-op
+	done1(ratelimit.DoneInfo{Err: errors.New("timeout")})
+	assert.False(t, alg.dropped)
+	assert.Equal(t, Stat{Limit: 2}, l.Stat())
+}
+
+func TestLimiterCanceled(t *testing.T) {
+	alg := &mockAlgorithm{limit: 1}
+	l := NewLimiter(alg)
+	done, err := l.Allow()
+	assert.Nil(t, err)
+	done(ratelimit.DoneInfo{Err: fmt.Errorf("wrapped: %w", context.Canceled)})
+	// the canceled request is neither a sample nor a drop
+	assert.Equal(t, 0, alg.inflight)
+	assert.False(t, alg.dropped)
+	assert.Equal(t, Stat{Limit: 1}, l.Stat())
+}
+
+func TestAIMD(t *testing.T) {
+	a := NewAIMD(WithInitialLimit(10), WithMaxLimit(11), WithTimeout(time.Second))
+	// the limit is not used
+	a.Update(time.Millisecond, 4, false)
+	assert.Equal(t, 10, a.Limit())
+	a.Update(time.Millisecond, 5, false)
+	assert.Equal(t, 11, a.Limit())
+	a.Update(time.Millisecond, 10, false)
+	assert.Equal(t, 11, a.Limit())
+
+	a.Update(time.Millisecond, 10, true)
+	assert.Equal(t, 9, a.Limit())
+	a.Update(2*time.Second, 10, false)
+	assert.Equal(t, 8, a.Limit())
+}
+
+func TestVegas(t *testing.T) {
+	v := NewVegas(WithInitialLimit(10))
+	for i := 0; i < 10; i++ {
+		v.Update(10*time.Millisecond, v.Limit(), false)
+	}
+	grown := v.Limit()
+	assert.Greater(t, grown, 10)
+
+	// the latency doubles, half of the requests are queued
+	for i := 0; i < 10; i++ {
+		v.Update(20*time.Millisecond, v.Limit(), false)
+	}
+	assert.Less(t, v.Limit(), grown)
+
+	limit := v.Limit()
+	v.Update(10*time.Millisecond, 0, false)
+	assert.Equal(t, limit, v.Limit())
+	v.Update(10*time.Millisecond, 0, true)
+	assert.Less(t, v.Limit(), limit)
+}
+
+func TestGradient2(t *testing.T) {
+	g := NewGradient2(WithInitialLimit(10), WithMaxLimit(100))
+	for i := 0; i < 100; i++ {
+		g.Update(10*time.Millisecond, g.Limit(), false)
+	}
+	grown := g.Limit()
+	assert.Greater(t, grown, 10)
+
+	for i := 0; i < 20; i++ {
+		g.Update(50*time.Millisecond, g.Limit(), false)
+	}
+	assert.Less(t, g.Limit(), grown)
+
+	limit := g.Limit()
+	g.Update(time.Second, 0, false)
+	assert.Equal(t, limit, g.Limit())
+}
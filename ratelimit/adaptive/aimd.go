@@ -0,0 +1,41 @@
+package adaptive
+
+import "time"
+
+var _ Algorithm = (*AIMD)(nil)
+
+// AIMD is the additive increase multiplicative decrease algorithm, the limit
+// is increased by one on a successful request, and multiplied by the backoff
+// ratio on a dropped request or a request slower than the timeout.
+type AIMD struct {
+	limit float64
+	opts  algorithmOptions
+}
+
+// NewAIMD returns an AIMD algorithm.
+func NewAIMD(opts ...AlgorithmOption) *AIMD {
+	opt := newAlgorithmOptions(algorithmOptions{
+		backoff: 0.9,
+		timeout: 5 * time.Second,
+	}, opts)
+	return &AIMD{
+		limit: opt.clamp(float64(opt.initial)),
+		opts:  opt,
+	}
+}
+
+// Limit returns the current concurrency limit.
+func (a *AIMD) Limit() int {
+	return int(a.limit)
+}
+
+// Update learns from a sample.
+func (a *AIMD) Update(rtt time.Duration, inflight int, dropped bool) {
+	switch {
+	case dropped || rtt > a.opts.timeout:
+		a.limit = a.opts.clamp(a.limit * a.opts.backoff)
+	case float64(inflight)*2 >= a.limit:
+		// only increase the limit if it is actually used
+		a.limit = a.opts.clamp(a.limit + 1)
+	}
+}
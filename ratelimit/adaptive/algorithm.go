@@ -0,0 +1,100 @@
+package adaptive
+
+import (
+	"math"
+	"time"
+)
+
+// AlgorithmOption is a function that configures the algorithms,
+// the options not used by an algorithm are ignored.
+type AlgorithmOption func(*algorithmOptions)
+
+// algorithmOptions of the algorithms.
+type algorithmOptions struct {
+	initial   int
+	min       int
+	max       int
+	smoothing float64
+
+	// aimd
+	backoff float64
+	timeout time.Duration
+	// gradient2
+	tolerance float64
+	window    int
+}
+
+// WithInitialLimit with the initial limit, default is 20.
+func WithInitialLimit(n int) AlgorithmOption {
+	return func(o *algorithmOptions) {
+		o.initial = n
+	}
+}
+
+// WithMinLimit with the minimum limit, default is 1.
+func WithMinLimit(n int) AlgorithmOption {
+	return func(o *algorithmOptions) {
+		o.min = n
+	}
+}
+
+// WithMaxLimit with the maximum limit, default is 1000.
+func WithMaxLimit(n int) AlgorithmOption {
+	return func(o *algorithmOptions) {
+		o.max = n
+	}
+}
+
+// WithSmoothing with the factor in (0, 1] by which a new limit is blended
+// into the current one, default is 1 for vegas and 0.2 for gradient2.
+func WithSmoothing(f float64) AlgorithmOption {
+	return func(o *algorithmOptions) {
+		o.smoothing = f
+	}
+}
+
+// WithBackoffRatio with the ratio the aimd limit is multiplied by
+// on a dropped request, default is 0.9.
+func WithBackoffRatio(r float64) AlgorithmOption {
+	return func(o *algorithmOptions) {
+		o.backoff = r
+	}
+}
+
+// WithTimeout with the latency above which aimd treats a request as dropped, default is 5s.
+func WithTimeout(d time.Duration) AlgorithmOption {
+	return func(o *algorithmOptions) {
+		o.timeout = d
+	}
+}
+
+// WithTolerance with the ratio the short term latency of gradient2 may exceed
+// the long term one before the limit is reduced, default is 1.5.
+func WithTolerance(t float64) AlgorithmOption {
+	return func(o *algorithmOptions) {
+		o.tolerance = t
+	}
+}
+
+// WithWindow with the number of samples of the long term latency average
+// of gradient2, default is 600.
+func WithWindow(n int) AlgorithmOption {
+	return func(o *algorithmOptions) {
+		o.window = n
+	}
+}
+
+func newAlgorithmOptions(def algorithmOptions, opts []AlgorithmOption) algorithmOptions {
+	def.initial = 20
+	def.min = 1
+	def.max = 1000
+	for _, o := range opts {
+		o(&def)
+	}
+	return def
+}
+
+// clamp bounds the limit by the minimum and maximum limit.
+func (o *algorithmOptions) clamp(limit float64) float64 {
+	return math.Max(float64(o.min), math.Min(float64(o.max), limit))
+}
@@ -0,0 +1,64 @@
+package adaptive
+
+import (
+	"math"
+	"time"
+)
+
+var _ Algorithm = (*Gradient2)(nil)
+
+// Gradient2 is a delay based algorithm which compares the short term latency
+// to an exponential moving average of the long term latency. The limit is
+// scaled by the gradient of the two (bounded to [0.5, 1]) plus a queue size of
+// sqrt(limit) to probe for more concurrency, so the limit grows while the
+// latency is stable and shrinks once the latency trends up.
+// Dropped requests are not used as a signal.
+type Gradient2 struct {
+	limit   float64
+	longRTT float64
+	samples int
+	opts    algorithmOptions
+}
+
+// NewGradient2 returns a Gradient2 algorithm.
+func NewGradient2(opts ...AlgorithmOption) *Gradient2 {
+	opt := newAlgorithmOptions(algorithmOptions{
+		smoothing: 0.2,
+		tolerance: 1.5,
+		window:    600,
+	}, opts)
+	return &Gradient2{
+		limit: opt.clamp(float64(opt.initial)),
+		opts:  opt,
+	}
+}
+
+// Limit returns the current concurrency limit.
+func (g *Gradient2) Limit() int {
+	return int(g.limit)
+}
+
+// Update learns from a sample.
+func (g *Gradient2) Update(rtt time.Duration, inflight int, _ bool) {
+	if rtt <= 0 {
+		return
+	}
+	shortRTT := float64(rtt)
+	// warm up the average with a simple mean of the first samples
+	if g.samples < g.opts.window {
+		g.samples++
+	}
+	g.longRTT += (shortRTT - g.longRTT) / float64(g.samples)
+	// the long term latency drifts far above the short term one after
+	// a period of overload, decay it to recover faster
+	if g.longRTT/shortRTT > 2 {
+		g.longRTT *= 0.95
+	}
+	if float64(inflight) < g.limit/2 {
+		// the limit is not used, there is no signal
+		return
+	}
+	gradient := math.Max(0.5, math.Min(1, g.opts.tolerance*g.longRTT/shortRTT))
+	limit := g.opts.clamp(g.limit*gradient + math.Sqrt(g.limit))
+	g.limit = g.opts.clamp((1-g.opts.smoothing)*g.limit + g.opts.smoothing*limit)
+}
@@ -0,0 +1,66 @@
+package adaptive
+
+import (
+	"math"
+	"time"
+)
+
+var _ Algorithm = (*Vegas)(nil)
+
+// Vegas is a delay based algorithm inspired by TCP Vegas, it estimates the
+// queue size from the ratio of the minimum latency (no load) to the sampled
+// latency, and grows the limit while the queue is short and shrinks it once
+// the queue is long. All thresholds scale with log10 of the limit.
+//
+// NOTE: the minimum latency is never reset, so a permanent latency increase
+// of the backend is taken as queueing.
+type Vegas struct {
+	limit     float64
+	rttNoLoad time.Duration
+	opts      algorithmOptions
+}
+
+// NewVegas returns a Vegas algorithm.
+func NewVegas(opts ...AlgorithmOption) *Vegas {
+	opt := newAlgorithmOptions(algorithmOptions{
+		smoothing: 1,
+	}, opts)
+	return &Vegas{
+		limit: opt.clamp(float64(opt.initial)),
+		opts:  opt,
+	}
+}
+
+// Limit returns the current concurrency limit.
+func (v *Vegas) Limit() int {
+	return int(v.limit)
+}
+
+// Update learns from a sample.
+func (v *Vegas) Update(rtt time.Duration, inflight int, dropped bool) {
+	if rtt <= 0 {
+		return
+	}
+	if v.rttNoLoad == 0 || rtt < v.rttNoLoad {
+		v.rttNoLoad = rtt
+	}
+	log := math.Max(1, math.Log10(v.limit))
+	var limit float64
+	switch queue := math.Ceil(v.limit * (1 - float64(v.rttNoLoad)/float64(rtt))); {
+	case dropped:
+		limit = v.limit - log
+	case float64(inflight)*2 < v.limit:
+		// the limit is not used, there is no signal
+		return
+	case queue <= log:
+		limit = v.limit + 6*log
+	case queue < 3*log:
+		limit = v.limit + log
+	case queue > 6*log:
+		limit = v.limit - log
+	default:
+		return
+	}
+	limit = v.opts.clamp(limit)
+	v.limit = (1-v.opts.smoothing)*v.limit + v.opts.smoothing*limit
+}
@@ -1,21 +1,27 @@
 package bbr
 
 import (
+	"context"
+	"errors"
 	"math"
-	"runtime"
 	"sync/atomic"
 	"time"
 
-	"github.com/go-kratos/aegis/internal/cpu"
-	"github.com/go-kratos/aegis/internal/window"
+	"github.com/go-kratos/aegis/clock"
 	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/go-kratos/aegis/window"
 )
 
 var (
-	gCPU  int64
-	decay = 0.95
+	criticalities = [...]ratelimit.Criticality{
+		ratelimit.Sheddable,
+		ratelimit.SheddablePlus,
+		ratelimit.Critical,
+		ratelimit.CriticalPlus,
+	}
 
-	_ ratelimit.Limiter = (*BBR)(nil)
+	_ ratelimit.Limiter     = (*BBR)(nil)
+	_ ratelimit.WaitLimiter = (*BBR)(nil)
 )
 
 type (
@@ -25,37 +31,18 @@ type (
 	Option func(*options)
 )
 
-func init() {
-	go cpuproc()
-}
-
-// cpu = cpuᵗ⁻¹ * decay + cpuᵗ * (1 - decay)
-func cpuproc() {
-	ticker := time.NewTicker(time.Millisecond * 500) // same to cpu sample rate
-	defer func() {
-		ticker.Stop()
-		if err := recover(); err != nil {
-			go cpuproc()
-		}
-	}()
-
-	// EMA algorithm: https://blog.csdn.net/m0_38106113/article/details/81542863
-	for range ticker.C {
-		stat := &cpu.Stat{}
-		cpu.ReadStat(stat)
-		stat.Usage = min(stat.Usage, 1000)
-		prevCPU := atomic.LoadInt64(&gCPU)
-		curCPU := int64(float64(prevCPU)*decay + float64(stat.Usage)*(1.0-decay))
-		atomic.StoreInt64(&gCPU, curCPU)
-	}
-}
-
-func min(l, r uint64) uint64 {
-	if l < r {
-		return l
-	}
-	return r
-}
+// Result is the classification of a completed request.
+type Result int
+
+const (
+	// ResultSuccess the request is counted into the pass and rt statistics.
+	ResultSuccess Result = iota
+	// ResultFailed the request is failed, it is excluded from the statistics
+	// since a fast failure says nothing about the capacity.
+	ResultFailed
+	// ResultCanceled the request is canceled by the caller, it is excluded from the statistics.
+	ResultCanceled
+)
 
 // Stat contains the metrics snapshot of bbr.
 type Stat struct {
@@ -64,6 +51,14 @@ type Stat struct {
 	MaxInFlight int64
 	MinRt       int64
 	MaxPass     int64
+	// Failed and Canceled are the total number of requests
+	// excluded from the statistics since the limiter is created.
+	Failed   int64
+	Canceled int64
+	// Allowed and Dropped are the total number of requests
+	// by criticality since the limiter is created.
+	Allowed map[ratelimit.Criticality]int64
+	Dropped map[ratelimit.Criticality]int64
 }
 
 // counterCache is used to cache maxPASS and minRt result.
@@ -84,6 +79,16 @@ type options struct {
 	CPUThreshold int64
 	// CPUQuota
 	CPUQuota float64
+	// Clock is the time source
+	Clock clock.Clock
+	// Classifier classifies the completed requests by DoneInfo.Err
+	Classifier func(err error) Result
+	// Ratios are the ratios of maxInFlight to shed the requests by criticality
+	Ratios [len(criticalities)]float64
+	// Signal is the overload signal, the cpu usage against CPUThreshold if nil
+	Signal Signal
+	// Sampler is the cpu sampler, DefaultCPUSampler if nil and Signal is nil
+	Sampler *CPUSampler
 }
 
 // WithWindow with window size.
@@ -114,6 +119,66 @@ func WithCPUQuota(quota float64) Option {
 	}
 }
 
+// WithClock with the time source, default is the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.Clock = c
+	}
+}
+
+// WithClassifier with the classifier of the completed requests by DoneInfo.Err,
+// by default nil is a success, context.Canceled is canceled and other errors are failed.
+func WithClassifier(f func(err error) Result) Option {
+	return func(o *options) {
+		o.Classifier = f
+	}
+}
+
+// WithCPUSampler with the cpu sampler, default is DefaultCPUSampler.
+// The sampler is not started by the limiter. DefaultCPUSampler is only
+// used by the default signal, with WithSignal the cpu of Stat is zero
+// unless a sampler is set.
+func WithCPUSampler(s *CPUSampler) Option {
+	return func(o *options) {
+		o.Sampler = s
+	}
+}
+
+// WithSignal with the overload signal which triggers the shedding,
+// default is the cpu usage reaching the cpu threshold.
+// Use AnyOf and AllOf to combine the signals, e.g.
+//
+//	bbr.WithSignal(bbr.AnyOf(bbr.CPU(800, 0), bbr.PSI(bbr.PSIMemory, 20)))
+func WithSignal(s Signal) Option {
+	return func(o *options) {
+		o.Signal = s
+	}
+}
+
+// WithCriticalityRatio with the ratio of the estimated max in-flight requests
+// above which the requests of the criticality are shed once overloaded.
+// By default the ratios are 0.5 for Sheddable, 0.75 for SheddablePlus
+// and 1 for Critical, and CriticalPlus requests are never shed, which is
+// the same as an infinite ratio.
+func WithCriticalityRatio(c ratelimit.Criticality, ratio float64) Option {
+	return func(o *options) {
+		if c >= ratelimit.Sheddable && c <= ratelimit.CriticalPlus {
+			o.Ratios[c] = ratio
+		}
+	}
+}
+
+// DefaultClassifier is the default classifier of the completed requests.
+func DefaultClassifier(err error) Result {
+	switch {
+	case err == nil:
+		return ResultSuccess
+	case errors.Is(err, context.Canceled):
+		return ResultCanceled
+	}
+	return ResultFailed
+}
+
 // BBR implements bbr-like limiter.
 // It is inspired by sentinel.
 // https://github.com/alibaba/Sentinel/wiki/%E7%B3%BB%E7%BB%9F%E8%87%AA%E9%80%82%E5%BA%94%E9%99%90%E6%B5%81
@@ -121,7 +186,12 @@ type BBR struct {
 	cpu             cpuGetter
 	passStat        window.RollingCounter
 	rtStat          window.RollingCounter
+	signal          Signal
 	inFlight        int64
+	failed          int64
+	canceled        int64
+	allowed         [len(criticalities)]int64
+	dropped         [len(criticalities)]int64
 	bucketPerSecond int64
 	bucketDuration  time.Duration
 
@@ -130,6 +200,9 @@ type BBR struct {
 	maxPASSCache atomic.Value
 	minRtCache   atomic.Value
 
+	// released wakes up one waiter when an inflight request is done
+	released chan struct{}
+
 	opts options
 }
 
@@ -139,14 +212,17 @@ func NewLimiter(opts ...Option) *BBR {
 		Window:       time.Second * 10,
 		Bucket:       100,
 		CPUThreshold: 800,
+		Classifier:   DefaultClassifier,
+		Ratios:       [...]float64{0.5, 0.75, 1, math.Inf(1)},
 	}
 	for _, o := range opts {
 		o(&opt)
 	}
+	opt.Clock = clock.OrNew(opt.Clock)
 
 	bucketDuration := opt.Window / time.Duration(opt.Bucket)
-	passStat := window.NewRollingCounter(window.RollingCounterOpts{Size: opt.Bucket, BucketDuration: bucketDuration})
-	rtStat := window.NewRollingCounter(window.RollingCounterOpts{Size: opt.Bucket, BucketDuration: bucketDuration})
+	passStat := window.NewRollingCounter(window.RollingCounterOpts{Size: opt.Bucket, BucketDuration: bucketDuration, Clock: opt.Clock})
+	rtStat := window.NewRollingCounter(window.RollingCounterOpts{Size: opt.Bucket, BucketDuration: bucketDuration, Clock: opt.Clock})
 
 	limiter := &BBR{
 		opts:            opt,
@@ -154,16 +230,32 @@ func NewLimiter(opts ...Option) *BBR {
 		rtStat:          rtStat,
 		bucketDuration:  bucketDuration,
 		bucketPerSecond: int64(time.Second / bucketDuration),
-		cpu:             func() int64 { return atomic.LoadInt64(&gCPU) },
+		released:        make(chan struct{}, 1),
 	}
-
-	if opt.CPUQuota != 0 {
-		// if cpuQuota is set, use new cpuGetter,Calculate the real CPU value based on the number of CPUs and Quota.
+	// if cpuQuota is set, the real CPU value is calculated based on the number of CPUs and Quota.
+	switch {
+	case opt.Sampler != nil:
 		limiter.cpu = func() int64 {
-			return int64(float64(atomic.LoadInt64(&gCPU)) * float64(runtime.NumCPU()) / opt.CPUQuota)
+			return opt.Sampler.scaled(opt.CPUQuota)
+		}
+	case opt.Signal == nil:
+		// the default sampler is started on the first read of the default signal
+		limiter.cpu = func() int64 {
+			return DefaultCPUSampler().scaled(opt.CPUQuota)
+		}
+	default:
+		// the cpu is not sampled for a custom signal without a sampler
+		limiter.cpu = func() int64 {
+			return 0
 		}
 	}
 
+	limiter.signal = opt.Signal
+	if limiter.signal == nil {
+		limiter.signal = SignalFunc(func() bool {
+			return limiter.cpu() >= limiter.opts.CPUThreshold
+		})
+	}
 	return limiter
 }
 
@@ -189,7 +281,7 @@ func (l *BBR) maxPASS() int64 {
 	}))
 	l.maxPASSCache.Store(&counterCache{
 		val:  rawMaxPass,
-		time: time.Now(),
+		time: l.opts.Clock.Now(),
 	})
 	return rawMaxPass
 }
@@ -198,7 +290,7 @@ func (l *BBR) maxPASS() int64 {
 // since lastTime, if it is one bucket duration earlier than
 // the last recorded time, it will return the BucketNum.
 func (l *BBR) timespan(lastTime time.Time) int {
-	v := int(time.Since(lastTime) / l.bucketDuration)
+	v := int(l.opts.Clock.Now().Sub(lastTime) / l.bucketDuration)
 	if v > -1 {
 		return v
 	}
@@ -234,7 +326,7 @@ func (l *BBR) minRT() int64 {
 	}
 	l.minRtCache.Store(&counterCache{
 		val:  rawMinRT,
-		time: time.Now(),
+		time: l.opts.Clock.Now(),
 	})
 	return rawMinRT
 }
@@ -243,10 +335,12 @@ func (l *BBR) maxInFlight() int64 {
 	return int64(math.Floor(float64(l.maxPASS()*l.minRT()*l.bucketPerSecond)/1000.0) + 0.5)
 }
 
-func (l *BBR) shouldDrop() bool {
-	now := time.Duration(time.Now().UnixNano())
-	if l.cpu() < l.opts.CPUThreshold {
-		// current cpu payload below the threshold
+// shouldDrop checks whether the request should be dropped, ratio scales
+// maxInFlight by the criticality of the request.
+func (l *BBR) shouldDrop(ratio float64) bool {
+	now := time.Duration(l.opts.Clock.Now().UnixNano())
+	if !l.signal.Overloaded() {
+		// current payload below the threshold
 		prevDropTime, _ := l.prevDropTime.Load().(time.Duration)
 		if prevDropTime == 0 {
 			// haven't start drop,
@@ -256,15 +350,13 @@ func (l *BBR) shouldDrop() bool {
 		if time.Duration(now-prevDropTime) <= time.Second {
 			// just start drop one second ago,
 			// check current inflight count
-			inFlight := atomic.LoadInt64(&l.inFlight)
-			return inFlight > 1 && inFlight > l.maxInFlight()
+			return l.exceeds(ratio)
 		}
 		l.prevDropTime.Store(time.Duration(0))
 		return false
 	}
-	// current cpu payload exceeds the threshold
-	inFlight := atomic.LoadInt64(&l.inFlight)
-	drop := inFlight > 1 && inFlight > l.maxInFlight()
+	// current payload exceeds the threshold
+	drop := l.exceeds(ratio)
 	if drop {
 		prevDrop, _ := l.prevDropTime.Load().(time.Duration)
 		if prevDrop != 0 {
@@ -277,32 +369,91 @@ func (l *BBR) shouldDrop() bool {
 	return drop
 }
 
+// exceeds checks whether inflight exceeds maxInFlight scaled by ratio.
+func (l *BBR) exceeds(ratio float64) bool {
+	inFlight := atomic.LoadInt64(&l.inFlight)
+	return inFlight > 1 && float64(inFlight) > float64(l.maxInFlight())*ratio
+}
+
 // Stat tasks a snapshot of the bbr limiter.
 func (l *BBR) Stat() Stat {
-	return Stat{
+	stat := Stat{
 		CPU:         l.cpu(),
 		MinRt:       l.minRT(),
 		MaxPass:     l.maxPASS(),
 		MaxInFlight: l.maxInFlight(),
 		InFlight:    atomic.LoadInt64(&l.inFlight),
+		Failed:      atomic.LoadInt64(&l.failed),
+		Canceled:    atomic.LoadInt64(&l.canceled),
+		Allowed:     make(map[ratelimit.Criticality]int64, len(criticalities)),
+		Dropped:     make(map[ratelimit.Criticality]int64, len(criticalities)),
 	}
+	for _, c := range criticalities {
+		stat.Allowed[c] = atomic.LoadInt64(&l.allowed[c])
+		stat.Dropped[c] = atomic.LoadInt64(&l.dropped[c])
+	}
+	return stat
 }
 
-// Allow checks all inbound traffic.
+// Allow checks all inbound traffic as Critical requests.
 // Once overload is detected, it raises limit.ErrLimitExceed error.
 func (l *BBR) Allow() (ratelimit.DoneFunc, error) {
-	if l.shouldDrop() {
+	return l.allow(ratelimit.Critical)
+}
+
+// AllowWithContext checks the inbound traffic by the criticality carried by ctx,
+// the requests of lower criticality are shed at lower in-flight thresholds.
+func (l *BBR) AllowWithContext(ctx context.Context) (ratelimit.DoneFunc, error) {
+	return l.allow(ratelimit.CriticalityFromContext(ctx))
+}
+
+func (l *BBR) allow(c ratelimit.Criticality) (ratelimit.DoneFunc, error) {
+	if l.shouldDrop(l.opts.Ratios[c]) {
+		atomic.AddInt64(&l.dropped[c], 1)
 		return nil, ratelimit.ErrLimitExceed
 	}
+	atomic.AddInt64(&l.allowed[c], 1)
 	atomic.AddInt64(&l.inFlight, 1)
-	start := time.Now().UnixNano()
+	start := l.opts.Clock.Now().UnixNano()
 	ms := float64(time.Millisecond)
-	return func(ratelimit.DoneInfo) {
-		//nolint
-		if rt := int64(math.Ceil(float64(time.Now().UnixNano()-start) / ms)); rt > 0 {
-			l.rtStat.Add(rt)
+	return func(info ratelimit.DoneInfo) {
+		switch l.opts.Classifier(info.Err) {
+		case ResultSuccess:
+			//nolint
+			if rt := int64(math.Ceil(float64(l.opts.Clock.Now().UnixNano()-start) / ms)); rt > 0 {
+				l.rtStat.Add(rt)
+			}
+			l.passStat.Add(1)
+		case ResultFailed:
+			atomic.AddInt64(&l.failed, 1)
+		case ResultCanceled:
+			atomic.AddInt64(&l.canceled, 1)
 		}
 		atomic.AddInt64(&l.inFlight, -1)
-		l.passStat.Add(1)
+		select {
+		case l.released <- struct{}{}:
+		default:
+		}
 	}, nil
 }
+
+// Wait blocks until the request is allowed or ctx is done, the request is
+// retried once an inflight request is done or every bucket duration.
+// The criticality of the request is carried by ctx.
+func (l *BBR) Wait(ctx context.Context) (ratelimit.DoneFunc, error) {
+	for {
+		done, err := l.AllowWithContext(ctx)
+		if err == nil {
+			return done, nil
+		}
+		timer := time.NewTimer(l.bucketDuration)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-l.released:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
@@ -1,13 +1,17 @@
 package bbr
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
-	"github.com/go-kratos/aegis/internal/window"
+	"github.com/go-kratos/aegis/clock"
 	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/go-kratos/aegis/window"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/exp/rand"
 )
@@ -185,23 +189,105 @@ func TestBBRShouldDrop(t *testing.T) {
 	// cpu >=  800, inflight < maxQps
 	cpu = 800
 	bbr.inFlight = 50
-	assert.Equal(t, false, bbr.shouldDrop())
+	assert.Equal(t, false, bbr.shouldDrop(1))
 
 	// cpu >=  800, inflight > maxQps
 	cpu = 800
 	bbr.inFlight = 80
-	assert.Equal(t, true, bbr.shouldDrop())
+	assert.Equal(t, true, bbr.shouldDrop(1))
 
 	// cpu < 800, inflight > maxQps, cold duration
 	cpu = 700
 	bbr.inFlight = 80
-	assert.Equal(t, true, bbr.shouldDrop())
+	assert.Equal(t, true, bbr.shouldDrop(1))
 
 	// cpu < 800, inflight > maxQps
 	time.Sleep(2 * time.Second)
 	cpu = 700
 	bbr.inFlight = 80
-	assert.Equal(t, false, bbr.shouldDrop())
+	assert.Equal(t, false, bbr.shouldDrop(1))
+}
+
+func TestBBRShouldDropWithClock(t *testing.T) {
+	var cpu int64
+	c := clock.NewFake(time.Now())
+	bbr := NewLimiter(append(optsForTest, WithClock(c))...)
+	bbr.cpu = func() int64 {
+		return cpu
+	}
+	bucketDuration := windowSizeTest / time.Duration(bucketNumTest)
+	for i := 0; i < 10; i++ {
+		bbr.passStat.Add(int64((i + 1) * 100))
+		for j := i*10 + 1; j <= i*10+10; j++ {
+			bbr.rtStat.Add(int64(j))
+		}
+		if i != 9 {
+			c.Advance(bucketDuration)
+		}
+	}
+	// value of the latest bucket is not counted
+	assert.Equal(t, int64(900), bbr.maxPASS())
+	assert.Equal(t, int64(6), bbr.minRT())
+	assert.Equal(t, int64(54), bbr.maxInFlight())
+
+	// cpu >=  800, inflight < maxQps
+	cpu = 800
+	bbr.inFlight = 50
+	assert.Equal(t, false, bbr.shouldDrop(1))
+
+	// cpu >=  800, inflight > maxQps
+	bbr.inFlight = 80
+	assert.Equal(t, true, bbr.shouldDrop(1))
+
+	// cpu < 800, inflight > maxQps, cold duration
+	cpu = 700
+	assert.Equal(t, true, bbr.shouldDrop(1))
+
+	// cpu < 800, inflight > maxQps
+	c.Advance(2 * time.Second)
+	assert.Equal(t, false, bbr.shouldDrop(1))
+
+	// round trip time is measured by the clock
+	bbr.inFlight = 0
+	done, err := bbr.Allow()
+	assert.Nil(t, err)
+	c.Advance(time.Second)
+	done(ratelimit.DoneInfo{})
+	assert.Equal(t, float64(1000), bbr.rtStat.Sum())
+}
+
+func TestBBRWait(t *testing.T) {
+	bbr := NewLimiter(optsForTest...)
+	bbr.cpu = func() int64 {
+		return 900
+	}
+	// allowed directly
+	done, err := bbr.Wait(context.Background())
+	assert.Nil(t, err)
+	done(ratelimit.DoneInfo{})
+
+	// maxInFlight is 1 without any statistics
+	atomic.StoreInt64(&bbr.inFlight, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = bbr.Wait(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// woken up once inflight requests are done
+	atomic.StoreInt64(&bbr.inFlight, 2)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done, err := bbr.Allow()
+		assert.NotNil(t, err)
+		assert.Nil(t, done)
+		atomic.AddInt64(&bbr.inFlight, -1)
+		bbr.released <- struct{}{}
+	}()
+	start := time.Now()
+	done, err = bbr.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Less(t, time.Since(start), windowSizeTest/time.Duration(bucketNumTest))
+	done(ratelimit.DoneInfo{})
 }
 
 func BenchmarkBBRAllowUnderLowLoad(b *testing.B) {
@@ -247,7 +333,7 @@ func BenchmarkBBRShouldDropUnderLowLoad(b *testing.B) {
 	warmup(bbr, 10000)
 	b.ResetTimer()
 	for i := 0; i <= b.N; i++ {
-		bbr.shouldDrop()
+		bbr.shouldDrop(1)
 	}
 }
 
@@ -260,7 +346,7 @@ func BenchmarkBBRShouldDropUnderHighLoad(b *testing.B) {
 	bbr.inFlight = 1000
 	b.ResetTimer()
 	for i := 0; i <= b.N; i++ {
-		bbr.shouldDrop()
+		bbr.shouldDrop(1)
 		if i%10000 == 0 {
 			forceAllow(bbr)
 		}
@@ -277,9 +363,90 @@ func BenchmarkBBRShouldDropUnderUnstableLoad(b *testing.B) {
 	bbr.inFlight = 1000
 	b.ResetTimer()
 	for i := 0; i <= b.N; i++ {
-		bbr.shouldDrop()
+		bbr.shouldDrop(1)
 		if i%100000 == 0 {
 			forceAllow(bbr)
 		}
 	}
 }
+
+func TestBBRDoneInfo(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	errTimeout := errors.New("timeout")
+	bbr := NewLimiter(append(optsForTest, WithClock(c), WithClassifier(func(err error) Result {
+		if errors.Is(err, errTimeout) {
+			return ResultSuccess
+		}
+		return DefaultClassifier(err)
+	}))...)
+	bbr.cpu = func() int64 {
+		return 0
+	}
+
+	finish := func(err error) {
+		done, e := bbr.Allow()
+		assert.Nil(t, e)
+		c.Advance(time.Millisecond)
+		done(ratelimit.DoneInfo{Err: err})
+	}
+	finish(nil)
+	finish(errors.New("db error"))
+	finish(fmt.Errorf("wrapped: %w", context.Canceled))
+	finish(errTimeout)
+
+	assert.Equal(t, float64(2), bbr.passStat.Sum())
+	assert.Equal(t, float64(2), bbr.rtStat.Sum())
+	stat := bbr.Stat()
+	assert.Equal(t, int64(1), stat.Failed)
+	assert.Equal(t, int64(1), stat.Canceled)
+	assert.Equal(t, int64(0), stat.InFlight)
+}
+
+func TestBBRCriticality(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	bbr := NewLimiter(append(optsForTest, WithClock(c), WithCriticalityRatio(ratelimit.SheddablePlus, 0.9))...)
+	bbr.cpu = func() int64 {
+		return 900
+	}
+	bucketDuration := windowSizeTest / time.Duration(bucketNumTest)
+	for i := 0; i < 10; i++ {
+		bbr.passStat.Add(100)
+		bbr.rtStat.Add(100)
+		c.Advance(bucketDuration)
+	}
+	// maxInFlight = 100 * 100ms * 10 / 1000
+	assert.Equal(t, int64(100), bbr.maxInFlight())
+
+	allow := func(criticality ratelimit.Criticality) error {
+		ctx := ratelimit.NewCriticalityContext(context.Background(), criticality)
+		_, err := bbr.AllowWithContext(ctx)
+		return err
+	}
+	bbr.inFlight = 60
+	assert.Equal(t, ratelimit.ErrLimitExceed, allow(ratelimit.Sheddable))
+	assert.Nil(t, allow(ratelimit.SheddablePlus))
+	bbr.inFlight = 95
+	assert.Equal(t, ratelimit.ErrLimitExceed, allow(ratelimit.SheddablePlus))
+	assert.Nil(t, allow(ratelimit.Critical))
+	_, err := bbr.Allow()
+	assert.Nil(t, err)
+	bbr.inFlight = 1000
+	assert.Equal(t, ratelimit.ErrLimitExceed, allow(ratelimit.Critical))
+	assert.Nil(t, allow(ratelimit.CriticalPlus))
+	// an unknown criticality is critical
+	assert.Equal(t, ratelimit.ErrLimitExceed, allow(ratelimit.Criticality(10)))
+
+	stat := bbr.Stat()
+	assert.Equal(t, map[ratelimit.Criticality]int64{
+		ratelimit.Sheddable:     0,
+		ratelimit.SheddablePlus: 1,
+		ratelimit.Critical:      2,
+		ratelimit.CriticalPlus:  1,
+	}, stat.Allowed)
+	assert.Equal(t, map[ratelimit.Criticality]int64{
+		ratelimit.Sheddable:     1,
+		ratelimit.SheddablePlus: 1,
+		ratelimit.Critical:      2,
+		ratelimit.CriticalPlus:  0,
+	}, stat.Dropped)
+}
@@ -0,0 +1,114 @@
+package bbr
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/internal/lru"
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+// GroupOption is a function that configures the Group.
+type GroupOption func(*Group)
+
+// WithLimiterOptions sets the options of every limiter in the group.
+func WithLimiterOptions(opts ...Option) GroupOption {
+	return func(g *Group) {
+		g.opts = append(g.opts, opts...)
+	}
+}
+
+// WithKeyOptions sets the options of the limiter of the given key,
+// they are applied after the options set by WithLimiterOptions.
+func WithKeyOptions(key string, opts ...Option) GroupOption {
+	return func(g *Group) {
+		g.keyOpts[key] = append(g.keyOpts[key], opts...)
+	}
+}
+
+// WithExpires sets the idle duration after which a limiter is evicted.
+// Zero means never expire, which is the default.
+func WithExpires(d time.Duration) GroupOption {
+	return func(g *Group) {
+		g.expires = d
+	}
+}
+
+// Group is a set of bbr limiters by key, e.g. by endpoint, so that the keys are
+// throttled independently once the process is overloaded. The limiters are
+// created lazily and share the same overload signal, by default the cpu usage
+// sampled by the process. A limiter with requests in flight is never evicted,
+// so its in-flight count is not lost.
+type Group struct {
+	limiters *lru.Cache[*BBR]
+
+	opts    []Option
+	keyOpts map[string][]Option
+	expires time.Duration
+}
+
+// NewGroup returns a group of bbr limiters.
+func NewGroup(opts ...GroupOption) *Group {
+	g := &Group{
+		keyOpts: make(map[string][]Option),
+	}
+	for _, o := range opts {
+		o(g)
+	}
+	// the group evicts the limiters by the clock of the limiters
+	opt := options{}
+	for _, o := range g.opts {
+		o(&opt)
+	}
+	g.limiters = lru.New(lru.Options[*BBR]{
+		Expires: g.expires,
+		Clock:   opt.Clock,
+		Pinned: func(l *BBR) bool {
+			return atomic.LoadInt64(&l.inFlight) > 0
+		},
+	})
+	return g
+}
+
+// Get returns the limiter of the given key.
+func (g *Group) Get(key string) *BBR {
+	return g.limiters.GetOrCreate(key, func() *BBR {
+		opts := g.opts
+		if keyOpts, ok := g.keyOpts[key]; ok {
+			opts = append(opts[:len(opts):len(opts)], keyOpts...)
+		}
+		return NewLimiter(opts...)
+	})
+}
+
+// Allow checks the inbound traffic of the given key as Critical requests.
+func (g *Group) Allow(key string) (ratelimit.DoneFunc, error) {
+	return g.Get(key).Allow()
+}
+
+// AllowWithContext checks the inbound traffic of the given key by the criticality carried by ctx.
+func (g *Group) AllowWithContext(ctx context.Context, key string) (ratelimit.DoneFunc, error) {
+	return g.Get(key).AllowWithContext(ctx)
+}
+
+// Remove removes the limiter of the given key,
+// it returns false if the key does not exist.
+func (g *Group) Remove(key string) bool {
+	return g.limiters.Remove(key)
+}
+
+// Len returns the number of limiters in the group.
+func (g *Group) Len() int {
+	return g.limiters.Len()
+}
+
+// Stat takes a snapshot of every limiter in the group by key.
+func (g *Group) Stat() map[string]Stat {
+	stats := make(map[string]Stat)
+	g.limiters.Range(func(key string, l *BBR) bool {
+		stats[key] = l.Stat()
+		return true
+	})
+	return stats
+}
@@ -0,0 +1,66 @@
+package bbr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	g := NewGroup(
+		WithLimiterOptions(append(optsForTest, WithClock(c))...),
+		WithKeyOptions("/heavy", WithSignal(overloaded)),
+		WithExpires(time.Minute),
+	)
+	light := g.Get("/light")
+	assert.Same(t, light, g.Get("/light"))
+	heavy := g.Get("/heavy")
+	assert.Equal(t, bucketNumTest, heavy.opts.Bucket)
+
+	// the keys are throttled independently
+	light.inFlight = 10
+	heavy.inFlight = 10
+	_, err := g.Allow("/light")
+	assert.Nil(t, err)
+	_, err = g.Allow("/heavy")
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	stats := g.Stat()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, int64(11), stats["/light"].InFlight)
+	assert.Equal(t, int64(1), stats["/heavy"].Dropped[ratelimit.Critical])
+
+	light.inFlight = 0
+	heavy.inFlight = 0
+	c.Advance(30 * time.Second)
+	g.Get("/light")
+	c.Advance(31 * time.Second)
+	assert.Equal(t, 1, g.Len())
+	assert.True(t, g.Remove("/light"))
+	assert.False(t, g.Remove("/light"))
+	assert.Equal(t, 0, g.Len())
+	// a new limiter is created after eviction
+	assert.NotSame(t, light, g.Get("/light"))
+}
+
+func TestGroupInFlight(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	g := NewGroup(WithLimiterOptions(append(optsForTest, WithClock(c))...), WithExpires(time.Minute))
+	l := g.Get("/slow")
+	done, err := g.Allow("/slow")
+	assert.Nil(t, err)
+
+	// the limiter with requests in flight is not evicted
+	c.Advance(2 * time.Minute)
+	assert.Equal(t, 1, g.Len())
+	assert.Same(t, l, g.Get("/slow"))
+	done(ratelimit.DoneInfo{})
+	assert.Equal(t, int64(0), l.Stat().InFlight)
+
+	c.Advance(2 * time.Minute)
+	assert.Equal(t, 0, g.Len())
+}
@@ -0,0 +1,194 @@
+package bbr
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/internal/cpu"
+)
+
+var (
+	defaultSampler     = NewCPUSampler()
+	defaultSamplerOnce sync.Once
+)
+
+// DefaultCPUSampler returns the cpu sampler shared by the limiters which are
+// created without WithCPUSampler, it is started on the first call.
+func DefaultCPUSampler() *CPUSampler {
+	defaultSamplerOnce.Do(func() {
+		// NOTE: the usage stays zero if the cpu stat is not available,
+		// the error is reported by Err.
+		_ = defaultSampler.Start()
+	})
+	return defaultSampler
+}
+
+// SamplerOption is a function that configures the CPUSampler.
+type SamplerOption func(*samplerOptions)
+
+// samplerOptions of cpu sampler.
+type samplerOptions struct {
+	interval time.Duration
+	decay    float64
+	onError  func(error)
+}
+
+// WithSampleInterval with the interval of sampling the cpu usage, default is 500ms.
+func WithSampleInterval(d time.Duration) SamplerOption {
+	return func(o *samplerOptions) {
+		o.interval = d
+	}
+}
+
+// WithDecay with the decay of the moving average of the cpu usage in [0, 1), default is 0.95.
+// cpu = cpuᵗ⁻¹ * decay + cpuᵗ * (1 - decay)
+func WithDecay(decay float64) SamplerOption {
+	return func(o *samplerOptions) {
+		o.decay = decay
+	}
+}
+
+// WithErrorHandler with the handler of the sampling errors, e.g. to log them.
+func WithErrorHandler(f func(error)) SamplerOption {
+	return func(o *samplerOptions) {
+		o.onError = f
+	}
+}
+
+// CPUSampler samples the cpu usage (in 1/1000) of the process periodically,
+// and keeps the exponential moving average of it.
+type CPUSampler struct {
+	usage int64
+	err   atomic.Value
+
+	mu     sync.Mutex
+	source cpu.CPU
+	stop   chan struct{}
+	done   chan struct{}
+
+	opts samplerOptions
+}
+
+// NewCPUSampler returns a cpu sampler, it does not sample until it is started.
+func NewCPUSampler(opts ...SamplerOption) *CPUSampler {
+	opt := samplerOptions{
+		interval: time.Millisecond * 500,
+		decay:    0.95,
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	return &CPUSampler{opts: opt}
+}
+
+// Start starts sampling in a goroutine, it returns an error if the cpu stat
+// is not available. Starting a started sampler is a no-op.
+func (s *CPUSampler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return nil
+	}
+	if s.source == nil {
+		source, err := cpu.New(s.opts.interval)
+		if err != nil {
+			s.report(err)
+			return err
+		}
+		s.source = source
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run(s.source, s.stop, s.done)
+	return nil
+}
+
+// Stop stops sampling and waits for the goroutine to exit, the last usage is kept.
+func (s *CPUSampler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop, s.done = nil, nil
+}
+
+// Usage returns the moving average of the cpu usage.
+func (s *CPUSampler) Usage() int64 {
+	return atomic.LoadInt64(&s.usage)
+}
+
+// Err returns the last sampling error.
+func (s *CPUSampler) Err() error {
+	v, _ := s.err.Load().(errorValue)
+	return v.err
+}
+
+// Signal returns a signal which is overloaded if the cpu usage reaches the
+// threshold. If quota is not zero, the usage is scaled by the number of cpus
+// divided by the quota, see WithCPUQuota.
+func (s *CPUSampler) Signal(threshold int64, quota float64) Signal {
+	return SignalFunc(func() bool {
+		return s.scaled(quota) >= threshold
+	})
+}
+
+// scaled returns the usage scaled by the cpu quota.
+func (s *CPUSampler) scaled(quota float64) int64 {
+	usage := s.Usage()
+	if quota != 0 {
+		usage = int64(float64(usage) * float64(runtime.NumCPU()) / quota)
+	}
+	return usage
+}
+
+func (s *CPUSampler) run(source cpu.CPU, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(s.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.sample(source)
+		}
+	}
+}
+
+func (s *CPUSampler) sample(source cpu.CPU) {
+	defer func() {
+		if p := recover(); p != nil {
+			s.report(fmt.Errorf("bbr: cpu sampler panic: %v", p))
+		}
+	}()
+	u, err := source.Usage()
+	if err != nil {
+		s.report(err)
+		return
+	}
+	// NOTE: an idle reading is zero, it decays the moving average
+	if u > 1000 {
+		u = 1000
+	}
+	prev := atomic.LoadInt64(&s.usage)
+	curr := int64(float64(prev)*s.opts.decay + float64(u)*(1.0-s.opts.decay))
+	atomic.StoreInt64(&s.usage, curr)
+}
+
+func (s *CPUSampler) report(err error) {
+	s.err.Store(errorValue{err: err})
+	if s.opts.onError != nil {
+		s.opts.onError(err)
+	}
+}
+
+// errorValue wraps the errors of different types stored in atomic.Value.
+type errorValue struct {
+	err error
+}
@@ -0,0 +1,116 @@
+package bbr
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/internal/cpu"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCPU struct {
+	usage int64
+	err   error
+}
+
+func (m *mockCPU) Usage() (uint64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	if m.usage < 0 {
+		panic("boom")
+	}
+	return uint64(atomic.LoadInt64(&m.usage)), nil
+}
+
+func (m *mockCPU) Info() cpu.Info {
+	return cpu.Info{}
+}
+
+func TestCPUSampler(t *testing.T) {
+	s := NewCPUSampler(WithSampleInterval(time.Millisecond), WithDecay(0.5))
+	s.source = &mockCPU{usage: 2000}
+	assert.Nil(t, s.Start())
+	assert.Nil(t, s.Start())
+	for s.Usage() < 990 {
+		time.Sleep(time.Millisecond)
+	}
+	s.Stop()
+	s.Stop()
+	// the usage is capped
+	usage := s.Usage()
+	assert.LessOrEqual(t, usage, int64(1000))
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, usage, s.Usage())
+
+	assert.True(t, s.Signal(900, 0).Overloaded())
+	assert.False(t, s.Signal(1000, 0).Overloaded())
+
+	bbr := NewLimiter(append(optsForTest, WithCPUSampler(s))...)
+	assert.Equal(t, usage, bbr.Stat().CPU)
+}
+
+func TestCPUSamplerError(t *testing.T) {
+	errUsage := errors.New("usage error")
+	reported := make(chan error, 10)
+	s := NewCPUSampler(WithSampleInterval(time.Millisecond), WithErrorHandler(func(err error) {
+		select {
+		case reported <- err:
+		default:
+		}
+	}))
+	source := &mockCPU{err: errUsage}
+	s.source = source
+	s.sample(source)
+	assert.Equal(t, errUsage, s.Err())
+	assert.Equal(t, errUsage, <-reported)
+
+	source.err = nil
+	source.usage = -1
+	s.sample(source)
+	assert.EqualError(t, s.Err(), "bbr: cpu sampler panic: boom")
+	assert.Equal(t, int64(0), s.Usage())
+}
+
+func TestCPUSamplerIdle(t *testing.T) {
+	s := NewCPUSampler(WithDecay(0.5))
+	source := &mockCPU{usage: 800}
+	s.sample(source)
+	assert.Equal(t, int64(400), s.Usage())
+	// the idle readings decay the usage
+	source.usage = 0
+	s.sample(source)
+	assert.Equal(t, int64(200), s.Usage())
+	for i := 0; i < 10; i++ {
+		s.sample(source)
+	}
+	assert.Equal(t, int64(0), s.Usage())
+}
+
+func TestDefaultCPUSamplerLazy(t *testing.T) {
+	prev := defaultSampler
+	defer func() {
+		defaultSampler = prev
+		defaultSamplerOnce = sync.Once{}
+	}()
+	defaultSampler = NewCPUSampler()
+	defaultSamplerOnce = sync.Once{}
+	defaultSampler.source = &mockCPU{usage: 500}
+
+	// the custom signal never reads the cpu
+	bbr := NewLimiter(append(optsForTest, WithSignal(SignalFunc(func() bool { return false })))...)
+	_, err := bbr.Allow()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), bbr.Stat().CPU)
+	assert.Nil(t, defaultSampler.stop)
+
+	// the default signal starts the default sampler
+	bbr = NewLimiter(optsForTest...)
+	_, err = bbr.Allow()
+	assert.Nil(t, err)
+	assert.NotNil(t, defaultSampler.stop)
+	defaultSampler.Stop()
+}
@@ -0,0 +1,298 @@
+package bbr
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/sysstat"
+)
+
+// Signal is an overload signal of the process, bbr starts to shed
+// the requests exceeding the estimated capacity once it is overloaded.
+// Implementations must be cheap and safe for concurrent use, since the
+// signal is checked on every request.
+type Signal interface {
+	Overloaded() bool
+}
+
+// SignalFunc is an adapter to use a function as a Signal.
+type SignalFunc func() bool
+
+// Overloaded calls f().
+func (f SignalFunc) Overloaded() bool {
+	return f()
+}
+
+// AnyOf returns a signal which is overloaded if any of the signals is overloaded.
+func AnyOf(signals ...Signal) Signal {
+	return SignalFunc(func() bool {
+		for _, s := range signals {
+			if s.Overloaded() {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// AllOf returns a signal which is overloaded if all of the signals are overloaded.
+func AllOf(signals ...Signal) Signal {
+	return SignalFunc(func() bool {
+		for _, s := range signals {
+			if !s.Overloaded() {
+				return false
+			}
+		}
+		return len(signals) > 0
+	})
+}
+
+// CPU returns a signal of the cpu usage sampled by DefaultCPUSampler,
+// see CPUSampler.Signal.
+func CPU(threshold int64, quota float64) Signal {
+	return DefaultCPUSampler().Signal(threshold, quota)
+}
+
+// Goroutines returns a signal which is overloaded if the number of goroutines exceeds the limit.
+func Goroutines(limit int) Signal {
+	return SignalFunc(func() bool {
+		return runtime.NumGoroutine() > limit
+	})
+}
+
+// Heap returns a signal which is overloaded if the bytes of allocated heap objects exceed the limit.
+// NOTE: the memory statistics are sampled at most once per second.
+func Heap(limit uint64, opts ...SignalOption) Signal {
+	m := newSignalOptions(opts).memStats
+	return SignalFunc(func() bool {
+		return m.load().heapAlloc > limit
+	})
+}
+
+// GCPause returns a signal which is overloaded if the fraction of the
+// wall time the program is paused by GC between the last two samples
+// exceeds the threshold, e.g. 0.05 for 5%.
+// NOTE: the memory statistics are sampled at most once per second.
+func GCPause(threshold float64, opts ...SignalOption) Signal {
+	m := newSignalOptions(opts).memStats
+	return SignalFunc(func() bool {
+		return m.load().pauseFraction > threshold
+	})
+}
+
+// memStats is the sampled memory statistics shared by the signals of the
+// wall clock, since runtime.ReadMemStats stops the world.
+var memStats = &memSampler{interval: time.Second, clock: clock.New()}
+
+// memSample is a sample of the memory statistics.
+type memSample struct {
+	time          time.Time
+	heapAlloc     uint64
+	pauseTotal    uint64
+	pauseFraction float64
+}
+
+// memSampler samples the memory statistics at most once per interval.
+type memSampler struct {
+	mu       sync.Mutex
+	sample   atomic.Value
+	interval time.Duration
+	clock    clock.Clock
+}
+
+func (m *memSampler) load() *memSample {
+	now := m.clock.Now()
+	if s, ok := m.sample.Load().(*memSample); ok && now.Sub(s.time) < m.interval {
+		return s
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev, ok := m.sample.Load().(*memSample)
+	if ok && now.Sub(prev.time) < m.interval {
+		return prev
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	s := &memSample{
+		time:       now,
+		heapAlloc:  stats.HeapAlloc,
+		pauseTotal: stats.PauseTotalNs,
+	}
+	if ok {
+		if elapsed := now.Sub(prev.time); elapsed > 0 {
+			s.pauseFraction = float64(s.pauseTotal-prev.pauseTotal) / float64(elapsed)
+		}
+	}
+	m.sample.Store(s)
+	return s
+}
+
+// PSIResource is a resource of the Linux pressure stall information.
+type PSIResource = sysstat.Resource
+
+const (
+	// PSICPU is the pressure of the cpu.
+	PSICPU = sysstat.CPU
+	// PSIMemory is the pressure of the memory.
+	PSIMemory = sysstat.Memory
+	// PSIIO is the pressure of the io.
+	PSIIO = sysstat.IO
+)
+
+// SignalOption is a function that configures the signals.
+type SignalOption func(*signalOptions)
+
+// signalOptions of the signals.
+type signalOptions struct {
+	clock clock.Clock
+	// memStats is the memory sampler of the clock
+	memStats *memSampler
+}
+
+// WithSignalClock with the time source of the signal, default is the wall clock.
+func WithSignalClock(c clock.Clock) SignalOption {
+	return func(o *signalOptions) {
+		o.clock = c
+	}
+}
+
+func newSignalOptions(opts []SignalOption) signalOptions {
+	opt := signalOptions{}
+	for _, o := range opts {
+		o(&opt)
+	}
+	if opt.clock == nil {
+		opt.clock = clock.New()
+		opt.memStats = memStats
+	} else {
+		opt.memStats = &memSampler{interval: time.Second, clock: opt.clock}
+	}
+	return opt
+}
+
+// PSI returns a signal which is overloaded if the percentage of the time
+// some tasks are stalled on the resource in the last 10 seconds (some avg10)
+// exceeds the threshold, e.g. 20 for 20%. It is never overloaded if the
+// pressure stall information is not available.
+// NOTE: the pressure is read at most once per second.
+func PSI(resource PSIResource, threshold float64, opts ...SignalOption) Signal {
+	s := &psiSignal{
+		reader:   sysstat.Default(),
+		resource: resource,
+		interval: time.Second,
+		clock:    newSignalOptions(opts).clock,
+	}
+	return SignalFunc(func() bool {
+		return s.avg10() > threshold
+	})
+}
+
+// psiSample is a sample of the pressure.
+type psiSample struct {
+	time  time.Time
+	avg10 float64
+}
+
+// psiSignal reads the pressure at most once per interval.
+type psiSignal struct {
+	mu     sync.Mutex
+	sample atomic.Value
+
+	reader   *sysstat.Reader
+	resource PSIResource
+	interval time.Duration
+	clock    clock.Clock
+}
+
+func (s *psiSignal) avg10() float64 {
+	now := s.clock.Now()
+	prev, ok := s.sample.Load().(*psiSample)
+	if ok && now.Sub(prev.time) < s.interval {
+		return prev.avg10
+	}
+	// the requests keep using the previous sample while the pressure is read
+	if !s.mu.TryLock() {
+		if ok {
+			return prev.avg10
+		}
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+	if prev, ok := s.sample.Load().(*psiSample); ok && now.Sub(prev.time) < s.interval {
+		return prev.avg10
+	}
+	sample := &psiSample{time: now}
+	if p, err := s.reader.Pressure(s.resource); err == nil {
+		sample.avg10 = p.Some.Avg10
+	}
+	s.sample.Store(sample)
+	return sample.avg10
+}
+
+// QueueLatency is a CoDel-like signal of the queueing latency, e.g. the time
+// requests wait in the accept queue or a worker pool before they are handled.
+// It is overloaded if the minimum latency observed within the last interval
+// exceeds the target, since a standing queue never drains. The window is stale
+// once no latency is evaluated for an interval, e.g. the traffic stops, and a
+// stale window is not overloaded.
+//
+// See https://queue.acm.org/detail.cfm?id=2209336.
+type QueueLatency struct {
+	mu sync.Mutex
+	// min is the minimum latency within the current interval
+	min        time.Duration
+	start      time.Time
+	overloaded int32
+	// evaluated is the unix nano when overloaded is evaluated
+	evaluated int64
+
+	target   time.Duration
+	interval time.Duration
+	clock    clock.Clock
+}
+
+var _ Signal = (*QueueLatency)(nil)
+
+// NewQueueLatency returns a queue latency signal with the target latency and interval, e.g. 5ms and 100ms.
+func NewQueueLatency(target, interval time.Duration, opts ...SignalOption) *QueueLatency {
+	c := newSignalOptions(opts).clock
+	return &QueueLatency{
+		min:      -1,
+		start:    c.Now(),
+		target:   target,
+		interval: interval,
+		clock:    c,
+	}
+}
+
+// Observe records the queueing latency of a request.
+func (q *QueueLatency) Observe(latency time.Duration) {
+	now := q.clock.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.min < 0 || latency < q.min {
+		q.min = latency
+	}
+	if now.Sub(q.start) < q.interval {
+		return
+	}
+	var overloaded int32
+	if q.min > q.target {
+		overloaded = 1
+	}
+	atomic.StoreInt32(&q.overloaded, overloaded)
+	atomic.StoreInt64(&q.evaluated, now.UnixNano())
+	q.min = -1
+	q.start = now
+}
+
+// Overloaded reports whether the minimum latency of the last interval exceeds the target.
+func (q *QueueLatency) Overloaded() bool {
+	if atomic.LoadInt32(&q.overloaded) == 0 {
+		return false
+	}
+	return q.clock.Now().UnixNano()-atomic.LoadInt64(&q.evaluated) < int64(q.interval)
+}
@@ -0,0 +1,135 @@
+package bbr
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/go-kratos/aegis/sysstat"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	overloaded = SignalFunc(func() bool { return true })
+	idle       = SignalFunc(func() bool { return false })
+)
+
+func TestSignalCombinators(t *testing.T) {
+	assert.True(t, AnyOf(idle, overloaded).Overloaded())
+	assert.False(t, AnyOf(idle, idle).Overloaded())
+	assert.False(t, AnyOf().Overloaded())
+	assert.True(t, AllOf(overloaded, overloaded).Overloaded())
+	assert.False(t, AllOf(overloaded, idle).Overloaded())
+	assert.False(t, AllOf().Overloaded())
+}
+
+func TestRuntimeSignals(t *testing.T) {
+	assert.True(t, Goroutines(0).Overloaded())
+	assert.False(t, Goroutines(math.MaxInt32).Overloaded())
+	assert.True(t, Heap(0).Overloaded())
+	assert.False(t, Heap(math.MaxUint64).Overloaded())
+	assert.False(t, GCPause(1).Overloaded())
+	assert.False(t, CPU(math.MaxInt64, 0).Overloaded())
+}
+
+func TestMemSignalsClock(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	heap := Heap(0, WithSignalClock(c))
+	assert.True(t, heap.Overloaded())
+	m := newSignalOptions([]SignalOption{WithSignalClock(c)}).memStats
+	assert.NotSame(t, memStats, m)
+	s := m.load()
+	// sampled by the signal clock
+	assert.Equal(t, c.Now(), s.time)
+	assert.Equal(t, s, m.load())
+	c.Advance(time.Second)
+	assert.NotEqual(t, s, m.load())
+	assert.False(t, GCPause(1, WithSignalClock(c)).Overloaded())
+}
+
+func TestPSI(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proc/pressure/memory": &fstest.MapFile{Data: []byte("some avg10=25.50 avg60=1.00 avg300=0.00 total=100\nfull avg10=5.00 avg60=0.00 avg300=0.00 total=10\n")},
+	}
+	c := clock.NewFake(time.Now())
+	s := &psiSignal{reader: sysstat.New(fsys), resource: PSIMemory, interval: time.Second, clock: c}
+	assert.Equal(t, 25.5, s.avg10())
+	// cached within the interval
+	fsys["proc/pressure/memory"] = &fstest.MapFile{Data: []byte("some avg10=1.00 avg60=1.00 avg300=0.00 total=100\n")}
+	assert.Equal(t, 25.5, s.avg10())
+	c.Advance(time.Second)
+	assert.Equal(t, 1.0, s.avg10())
+
+	// not available
+	s.resource = PSIIO
+	c.Advance(time.Second)
+	assert.Equal(t, 0.0, s.avg10())
+	assert.False(t, PSI("none", 0, WithSignalClock(c)).Overloaded())
+}
+
+func TestPSIConcurrent(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proc/pressure/cpu": &fstest.MapFile{Data: []byte("some avg10=25.50 avg60=1.00 avg300=0.00 total=100\n")},
+	}
+	c := clock.NewFake(time.Now())
+	s := &psiSignal{reader: sysstat.New(fsys), resource: PSICPU, interval: time.Second, clock: c}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.Equal(t, 25.5, s.avg10())
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestQueueLatency(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	q := NewQueueLatency(5*time.Millisecond, 100*time.Millisecond, WithSignalClock(c))
+
+	// a burst of slow requests within the interval is fine as long as one is fast
+	q.Observe(20 * time.Millisecond)
+	q.Observe(time.Millisecond)
+	c.Advance(100 * time.Millisecond)
+	q.Observe(20 * time.Millisecond)
+	assert.False(t, q.Overloaded())
+
+	// a standing queue
+	c.Advance(100 * time.Millisecond)
+	q.Observe(10 * time.Millisecond)
+	assert.True(t, q.Overloaded())
+
+	c.Advance(100 * time.Millisecond)
+	q.Observe(time.Millisecond)
+	assert.False(t, q.Overloaded())
+
+	// the window is stale once the traffic stops
+	c.Advance(100 * time.Millisecond)
+	q.Observe(10 * time.Millisecond)
+	c.Advance(100 * time.Millisecond)
+	q.Observe(10 * time.Millisecond)
+	assert.True(t, q.Overloaded())
+	c.Advance(99 * time.Millisecond)
+	assert.True(t, q.Overloaded())
+	c.Advance(time.Millisecond)
+	assert.False(t, q.Overloaded())
+}
+
+func TestBBRSignal(t *testing.T) {
+	signal := false
+	bbr := NewLimiter(append(optsForTest, WithSignal(SignalFunc(func() bool { return signal })))...)
+	bbr.inFlight = 10
+	_, err := bbr.Allow()
+	assert.Nil(t, err)
+
+	signal = true
+	_, err = bbr.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+}
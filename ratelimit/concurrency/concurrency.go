@@ -0,0 +1,199 @@
+package concurrency
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+var (
+	_ ratelimit.Limiter     = (*Limiter)(nil)
+	_ ratelimit.WaitLimiter = (*Limiter)(nil)
+)
+
+// Option is a function that configures the Limiter and Group.
+type Option func(*options)
+
+// options of concurrency limiter.
+type options struct {
+	queue        int
+	queueTimeout time.Duration
+}
+
+// WithQueue with the maximum number of requests waiting for a slot,
+// default is 0 which rejects the requests immediately once the limit is reached.
+func WithQueue(n int) Option {
+	return func(o *options) {
+		o.queue = n
+	}
+}
+
+// WithQueueTimeout with the maximum duration a request waits in the queue,
+// zero means Wait waits until a slot is released or its context is done,
+// and Allow rejects the request immediately instead of waiting, which is the default.
+func WithQueueTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.queueTimeout = d
+	}
+}
+
+// Stat is the statistics of the concurrency limiter.
+type Stat struct {
+	InFlight int64
+	Queued   int64
+}
+
+// Limiter is a concurrency limiter (bulkhead), it allows at most limit requests
+// in flight, the slot of a request is released by its done function.
+// Once the limit is reached, the requests wait in a bounded FIFO queue if configured.
+type Limiter struct {
+	mu    sync.Mutex
+	state state
+
+	limit int64
+	opts  options
+}
+
+// NewLimiter returns a concurrency limiter which allows at most limit requests in flight.
+func NewLimiter(limit int64, opts ...Option) *Limiter {
+	return &Limiter{
+		limit: limit,
+		opts:  newOptions(opts),
+	}
+}
+
+// Allow acquires a slot, it waits in the queue at most the queue timeout,
+// and it never waits if the queue timeout is not set.
+func (l *Limiter) Allow() (ratelimit.DoneFunc, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.state.acquire(context.Background(), &l.mu, l.limit, l.opts.allow()); err != nil {
+		return nil, err
+	}
+	return l.done(), nil
+}
+
+// Wait acquires a slot, it waits in the queue until ctx is done
+// or the queue timeout elapses.
+func (l *Limiter) Wait(ctx context.Context) (ratelimit.DoneFunc, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.state.acquire(ctx, &l.mu, l.limit, &l.opts); err != nil {
+		return nil, err
+	}
+	return l.done(), nil
+}
+
+// Stat returns the statistics of the limiter.
+func (l *Limiter) Stat() Stat {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state.stat()
+}
+
+func (l *Limiter) done() ratelimit.DoneFunc {
+	var released int32
+	return func(ratelimit.DoneInfo) {
+		if !atomic.CompareAndSwapInt32(&released, 0, 1) {
+			return
+		}
+		l.mu.Lock()
+		l.state.release()
+		l.mu.Unlock()
+	}
+}
+
+func newOptions(opts []Option) options {
+	opt := options{}
+	for _, o := range opts {
+		o(&opt)
+	}
+	return opt
+}
+
+// allow returns the options of Allow, which must not wait without a bound.
+func (o *options) allow() *options {
+	if o.queueTimeout > 0 {
+		return o
+	}
+	return &options{}
+}
+
+// state is the in-flight and queued requests of a limiter,
+// all the methods must be called with the lock of the limiter held.
+type state struct {
+	inflight int64
+	// queue is the waiters from the oldest to the newest
+	queue list.List
+}
+
+// waiter is a queued request.
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// acquire acquires a slot, the lock is released while waiting in the queue.
+func (s *state) acquire(ctx context.Context, mu *sync.Mutex, limit int64, opt *options) error {
+	if s.inflight < limit && s.queue.Len() == 0 {
+		s.inflight++
+		return nil
+	}
+	if s.queue.Len() >= opt.queue {
+		return ratelimit.ErrLimitExceed
+	}
+	w := &waiter{ready: make(chan struct{})}
+	elem := s.queue.PushBack(w)
+	mu.Unlock()
+	err := wait(ctx, w.ready, opt.queueTimeout)
+	mu.Lock()
+	if w.granted {
+		// the slot is handed over even if the wait is timed out in the meantime
+		return nil
+	}
+	s.queue.Remove(elem)
+	return err
+}
+
+// release hands the slot over to the oldest waiter if any.
+func (s *state) release() {
+	if elem := s.queue.Front(); elem != nil {
+		w := s.queue.Remove(elem).(*waiter)
+		w.granted = true
+		close(w.ready)
+		return
+	}
+	s.inflight--
+}
+
+func (s *state) idle() bool {
+	return s.inflight == 0 && s.queue.Len() == 0
+}
+
+func (s *state) stat() Stat {
+	return Stat{
+		InFlight: s.inflight,
+		Queued:   int64(s.queue.Len()),
+	}
+}
+
+func wait(ctx context.Context, ready <-chan struct{}, timeout time.Duration) error {
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case <-ready:
+		return nil
+	case <-timer:
+		return ratelimit.ErrLimitExceed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
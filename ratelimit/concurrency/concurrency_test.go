@@ -0,0 +1,145 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(2)
+	done1, err := l.Allow()
+	assert.Nil(t, err)
+	done2, err := l.Allow()
+	assert.Nil(t, err)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+	assert.Equal(t, Stat{InFlight: 2}, l.Stat())
+
+	done1(ratelimit.DoneInfo{})
+	// calling done twice releases only one slot
+	done1(ratelimit.DoneInfo{})
+	assert.Equal(t, Stat{InFlight: 1}, l.Stat())
+	done2(ratelimit.DoneInfo{})
+	assert.Equal(t, Stat{}, l.Stat())
+}
+
+func TestLimiterQueue(t *testing.T) {
+	l := NewLimiter(1, WithQueue(1), WithQueueTimeout(time.Second))
+	done, err := l.Allow()
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		done, err := l.Allow()
+		assert.Nil(t, err)
+		done(ratelimit.DoneInfo{})
+	}()
+	for l.Stat().Queued == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, Stat{InFlight: 1, Queued: 1}, l.Stat())
+	// the queue is full
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	done(ratelimit.DoneInfo{})
+	wg.Wait()
+	assert.Equal(t, Stat{}, l.Stat())
+}
+
+func TestLimiterQueueTimeout(t *testing.T) {
+	l := NewLimiter(1, WithQueue(2), WithQueueTimeout(10*time.Millisecond))
+	done, err := l.Allow()
+	assert.Nil(t, err)
+	defer done(ratelimit.DoneInfo{})
+
+	start := time.Now()
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = l.Wait(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, Stat{InFlight: 1}, l.Stat())
+}
+
+func TestLimiterAllowNotWait(t *testing.T) {
+	// Allow never blocks without the queue timeout
+	l := NewLimiter(1, WithQueue(1))
+	done, err := l.Allow()
+	assert.Nil(t, err)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	g := NewGroup(1, WithQueue(1))
+	doneA, err := g.Allow("a")
+	assert.Nil(t, err)
+	_, err = g.Allow("a")
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	// Wait still queues the request
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.Wait(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	go done(ratelimit.DoneInfo{})
+	done, err = l.Wait(context.Background())
+	assert.Nil(t, err)
+	done(ratelimit.DoneInfo{})
+	doneA(ratelimit.DoneInfo{})
+	assert.Equal(t, 0, g.Len())
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGroup(1)
+	doneA, err := g.Allow("a")
+	assert.Nil(t, err)
+	_, err = g.Get("a").Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+	doneB, err := g.Allow("b")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, g.Len())
+	assert.Equal(t, Stat{InFlight: 1}, g.Stat("a"))
+
+	doneA(ratelimit.DoneInfo{})
+	doneB(ratelimit.DoneInfo{})
+	assert.Equal(t, 0, g.Len())
+	assert.Equal(t, Stat{}, g.Stat("a"))
+}
+
+func TestGroupConcurrent(t *testing.T) {
+	g := NewGroup(3, WithQueue(100))
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		inflight int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := g.Wait(context.Background(), "key")
+			assert.Nil(t, err)
+			mu.Lock()
+			inflight++
+			assert.LessOrEqual(t, inflight, 3)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			inflight--
+			mu.Unlock()
+			done(ratelimit.DoneInfo{})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 0, g.Len())
+}
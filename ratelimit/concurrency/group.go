@@ -0,0 +1,113 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+// Group is a set of concurrency limiters by key, each key allows at most limit
+// requests in flight. The state of a key is dropped once it has no requests
+// in flight or queued, so the memory is bounded by the in-flight requests.
+type Group struct {
+	mu     sync.Mutex
+	states map[string]*state
+
+	limit int64
+	opts  options
+}
+
+// NewGroup returns a group which allows at most limit requests in flight per key.
+func NewGroup(limit int64, opts ...Option) *Group {
+	return &Group{
+		states: make(map[string]*state),
+		limit:  limit,
+		opts:   newOptions(opts),
+	}
+}
+
+// Allow acquires a slot of the given key, it waits in the queue at most the queue timeout,
+// and it never waits if the queue timeout is not set.
+func (g *Group) Allow(key string) (ratelimit.DoneFunc, error) {
+	return g.acquire(context.Background(), key, g.opts.allow())
+}
+
+// Wait acquires a slot of the given key, it waits in the queue until ctx is done
+// or the queue timeout elapses.
+func (g *Group) Wait(ctx context.Context, key string) (ratelimit.DoneFunc, error) {
+	return g.acquire(ctx, key, &g.opts)
+}
+
+func (g *Group) acquire(ctx context.Context, key string, opts *options) (ratelimit.DoneFunc, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.states[key]
+	if !ok {
+		s = &state{}
+		g.states[key] = s
+	}
+	err := s.acquire(ctx, &g.mu, g.limit, opts)
+	if err != nil {
+		g.dropIdle(key, s)
+		return nil, err
+	}
+	return g.done(key, s), nil
+}
+
+// Get returns a ratelimit.Limiter bound to the given key.
+func (g *Group) Get(key string) ratelimit.Limiter {
+	return &boundLimiter{group: g, key: key}
+}
+
+// Stat returns the statistics of the given key.
+func (g *Group) Stat(key string) Stat {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if s, ok := g.states[key]; ok {
+		return s.stat()
+	}
+	return Stat{}
+}
+
+// Len returns the number of keys with requests in flight or queued.
+func (g *Group) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.states)
+}
+
+func (g *Group) done(key string, s *state) ratelimit.DoneFunc {
+	var released int32
+	return func(ratelimit.DoneInfo) {
+		if !atomic.CompareAndSwapInt32(&released, 0, 1) {
+			return
+		}
+		g.mu.Lock()
+		s.release()
+		g.dropIdle(key, s)
+		g.mu.Unlock()
+	}
+}
+
+// dropIdle must be called with the lock held.
+func (g *Group) dropIdle(key string, s *state) {
+	if s.idle() && g.states[key] == s {
+		delete(g.states, key)
+	}
+}
+
+// boundLimiter is a ratelimit.Limiter of a key.
+type boundLimiter struct {
+	group *Group
+	key   string
+}
+
+func (b *boundLimiter) Allow() (ratelimit.DoneFunc, error) {
+	return b.group.Allow(b.key)
+}
+
+func (b *boundLimiter) Wait(ctx context.Context) (ratelimit.DoneFunc, error) {
+	return b.group.Wait(ctx, b.key)
+}
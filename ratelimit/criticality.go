@@ -0,0 +1,50 @@
+package ratelimit
+
+import "context"
+
+// Criticality is the priority of a request when the limiter sheds load,
+// the requests of lower criticality are shed first.
+type Criticality int
+
+const (
+	// Sheddable is the lowest criticality, e.g. batch and background jobs.
+	Sheddable Criticality = iota
+	// SheddablePlus is for the requests which can be retried later.
+	SheddablePlus
+	// Critical is the default criticality of the requests.
+	Critical
+	// CriticalPlus is the highest criticality, e.g. health checks and paid-tier traffic.
+	CriticalPlus
+)
+
+// String returns the name of the criticality.
+func (c Criticality) String() string {
+	switch c {
+	case Sheddable:
+		return "sheddable"
+	case SheddablePlus:
+		return "sheddable-plus"
+	case Critical:
+		return "critical"
+	case CriticalPlus:
+		return "critical-plus"
+	}
+	return "unknown"
+}
+
+type criticalityKey struct{}
+
+// NewCriticalityContext returns a new context that carries the criticality.
+func NewCriticalityContext(ctx context.Context, c Criticality) context.Context {
+	return context.WithValue(ctx, criticalityKey{}, c)
+}
+
+// CriticalityFromContext returns the criticality carried by ctx,
+// it is Critical if ctx carries no or an unknown criticality.
+func CriticalityFromContext(ctx context.Context) Criticality {
+	c, ok := ctx.Value(criticalityKey{}).(Criticality)
+	if !ok || c < Sheddable || c > CriticalPlus {
+		return Critical
+	}
+	return c
+}
@@ -0,0 +1,261 @@
+package distributed
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+var _ ratelimit.Limiter = (*Limiter)(nil)
+
+// Option is a function that configures the Limiter.
+type Option func(*options)
+
+// options of distributed limiter.
+type options struct {
+	batch    int64
+	timeout  time.Duration
+	retries  int
+	failOpen bool
+	clock    clock.Clock
+}
+
+// WithBatch with the number of tokens leased from the store at once, default is 10.
+// A larger batch saves round trips to the store, but the tokens leased by one
+// replica are not available to the others until the window ends.
+func WithBatch(n int64) Option {
+	return func(o *options) {
+		o.batch = n
+	}
+}
+
+// WithTimeout with the timeout of a store call, default is 100ms.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithRetries with the number of compare-and-swap retries on contention, default is 3.
+func WithRetries(n int) Option {
+	return func(o *options) {
+		o.retries = n
+	}
+}
+
+// WithFailOpen allows the requests when the store is unavailable,
+// by default the store error is returned and the requests are rejected.
+// After a store error, the store is not called again for the timeout duration.
+func WithFailOpen() Option {
+	return func(o *options) {
+		o.failOpen = true
+	}
+}
+
+// WithClock with the time source, default is the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// Limiter is a fixed window limiter shared by all the replicas using the same
+// store and key, it allows limit requests per window in total.
+//
+// To avoid a round trip per request, each replica leases a batch of tokens
+// from the window counter of the store, and serves the requests locally
+// until the batch is used up. The lease only succeeds if the counter stays
+// within the limit, so the limit is never exceeded across the replicas.
+//
+// NOTE: the windows are aligned by the clock of each replica, so the clocks
+// of the replicas are expected to be synchronized.
+type Limiter struct {
+	mu sync.Mutex
+	// start is the start of the current window
+	start time.Time
+	// tokens is the number of leased tokens left in the current window
+	tokens int64
+	// exhausted defines whether the store has no tokens left in the current window
+	exhausted bool
+	// err is the last store error, the store is not called again until retryAt
+	err     error
+	retryAt time.Time
+	// leasing is the lease in flight, nil if there is none
+	leasing *leaseCall
+
+	store  Store
+	key    string
+	limit  int64
+	window time.Duration
+	opts   options
+}
+
+// NewLimiter returns a distributed limiter which allows limit requests per window
+// of the given key in the store.
+func NewLimiter(store Store, key string, limit int64, window time.Duration, opts ...Option) *Limiter {
+	opt := options{
+		batch:   10,
+		timeout: 100 * time.Millisecond,
+		retries: 3,
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	if opt.batch < 1 {
+		opt.batch = 1
+	}
+	opt.clock = clock.OrNew(opt.clock)
+	return &Limiter{
+		store:  store,
+		key:    key,
+		limit:  limit,
+		window: window,
+		opts:   opt,
+	}
+}
+
+// Allow checks whether the request is within the limit of the current window.
+// The lock is not held while the tokens are leased from the store, so the
+// requests served by the tokens leased locally are not blocked by the store,
+// and only one lease is in flight at a time, the others wait for its result.
+func (l *Limiter) Allow() (ratelimit.DoneFunc, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for {
+		now := l.opts.clock.Now()
+		if start := now.Truncate(l.window); !start.Equal(l.start) {
+			l.start = start
+			l.tokens = 0
+			l.exhausted = false
+		}
+		if l.tokens > 0 {
+			l.tokens--
+			return done, nil
+		}
+		if l.exhausted {
+			return nil, ratelimit.ErrLimitExceed
+		}
+		if now.Before(l.retryAt) {
+			return l.fail(l.err)
+		}
+		call := l.leasing
+		if call == nil {
+			call = &leaseCall{start: l.start, done: make(chan struct{})}
+			l.leasing = call
+			l.mu.Unlock()
+			call.tokens, call.err = l.lease(call.start)
+			l.mu.Lock()
+			l.leasing = nil
+			close(call.done)
+			l.apply(call)
+		} else {
+			l.mu.Unlock()
+			<-call.done
+			l.mu.Lock()
+		}
+		if call.err != nil {
+			return l.fail(call.err)
+		}
+	}
+}
+
+// leaseCall is a lease in flight, the requests waiting for it are
+// served by its tokens once it is done.
+type leaseCall struct {
+	start  time.Time
+	done   chan struct{}
+	tokens int64
+	err    error
+}
+
+// apply must be called with the lock held, the tokens leased for
+// a previous window are dropped.
+func (l *Limiter) apply(call *leaseCall) {
+	if call.err != nil {
+		l.err = call.err
+		l.retryAt = l.opts.clock.Now().Add(l.opts.timeout)
+		return
+	}
+	if !call.start.Equal(l.start) {
+		return
+	}
+	l.tokens += call.tokens
+	l.exhausted = call.tokens == 0
+}
+
+func (l *Limiter) fail(err error) (ratelimit.DoneFunc, error) {
+	if l.opts.failOpen {
+		return done, nil
+	}
+	return nil, err
+}
+
+// Close returns the tokens left in the current window to the store,
+// so that the other replicas can use them.
+func (l *Limiter) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tokens == 0 || !l.start.Equal(l.opts.clock.Now().Truncate(l.window)) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), l.opts.timeout)
+	defer cancel()
+	_, err := l.store.Incr(ctx, l.storeKey(l.start), -l.tokens, l.ttl())
+	l.tokens = 0
+	return err
+}
+
+// lease is called without the lock held, it returns the number of tokens leased
+// from the store for the window of start, zero means no tokens are left in the window.
+func (l *Limiter) lease(start time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.opts.timeout)
+	defer cancel()
+	key, ttl := l.storeKey(start), l.ttl()
+	for i := 0; i <= l.opts.retries; i++ {
+		used, err := l.store.Incr(ctx, key, 0, ttl)
+		if err != nil {
+			return 0, err
+		}
+		n := l.limit - used
+		if n <= 0 {
+			return 0, nil
+		}
+		if n > l.opts.batch {
+			n = l.opts.batch
+		}
+		swapped, err := l.store.CompareAndSwap(ctx, key, used, used+n, ttl)
+		if err != nil {
+			return 0, err
+		}
+		if swapped {
+			return n, nil
+		}
+	}
+	// NOTE: under heavy contention, fall back to lease a single token by increment,
+	// the counter may exceed the limit but the excess tokens are never granted.
+	used, err := l.store.Incr(ctx, key, 1, ttl)
+	if err != nil {
+		return 0, err
+	}
+	if used > l.limit {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// storeKey returns the key of the counter of the window of start in the store.
+func (l *Limiter) storeKey(start time.Time) string {
+	return l.key + ":" + strconv.FormatInt(start.UnixNano()/int64(l.window), 10)
+}
+
+// ttl returns the ttl of the window counters, it covers the clock skew
+// of the replicas by keeping the counter for one more window.
+func (l *Limiter) ttl() time.Duration {
+	return 2 * l.window
+}
+
+func done(ratelimit.DoneInfo) {}
@@ -0,0 +1,191 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func allowed(l ratelimit.Limiter, n int) (count int) {
+	for i := 0; i < n; i++ {
+		if _, err := l.Allow(); err == nil {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLimiter(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	store := NewMemoryStore(clk)
+	l1 := NewLimiter(store, "test", 25, time.Second, WithBatch(10), WithClock(clk))
+	l2 := NewLimiter(store, "test", 25, time.Second, WithBatch(10), WithClock(clk))
+
+	assert.Equal(t, 1, allowed(l1, 1))
+	assert.Equal(t, 15, allowed(l2, 100))
+	assert.Equal(t, 9, allowed(l1, 100))
+
+	// the next window
+	clk.Advance(time.Second)
+	assert.Equal(t, 25, allowed(l2, 100))
+	_, err := l1.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+}
+
+func TestLimiterClose(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	store := NewMemoryStore(clk)
+	l1 := NewLimiter(store, "test", 20, time.Second, WithBatch(10), WithClock(clk))
+	l2 := NewLimiter(store, "test", 20, time.Second, WithBatch(10), WithClock(clk))
+
+	assert.Equal(t, 1, allowed(l1, 1))
+	assert.Nil(t, l1.Close())
+	assert.Equal(t, 19, allowed(l2, 100))
+}
+
+type errStore struct {
+	calls int
+}
+
+func (s *errStore) Incr(context.Context, string, int64, time.Duration) (int64, error) {
+	s.calls++
+	return 0, errors.New("unavailable")
+}
+
+func (s *errStore) CompareAndSwap(context.Context, string, int64, int64, time.Duration) (bool, error) {
+	s.calls++
+	return false, errors.New("unavailable")
+}
+
+func TestLimiterStoreError(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	store := &errStore{}
+	l := NewLimiter(store, "test", 10, time.Second, WithClock(clk))
+	_, err := l.Allow()
+	assert.NotNil(t, err)
+	_, err = l.Allow()
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, store.calls)
+
+	l = NewLimiter(store, "test", 10, time.Second, WithClock(clk), WithFailOpen())
+	assert.Equal(t, 10, allowed(l, 10))
+	clk.Advance(100 * time.Millisecond)
+	assert.Equal(t, 10, allowed(l, 10))
+	assert.Equal(t, 3, store.calls)
+}
+
+// slowStore blocks the leases until release is closed.
+type slowStore struct {
+	Store
+	leases  int32
+	release chan struct{}
+}
+
+func (s *slowStore) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	if delta == 0 {
+		atomic.AddInt32(&s.leases, 1)
+		<-s.release
+	}
+	return s.Store.Incr(ctx, key, delta, ttl)
+}
+
+func TestLimiterConcurrentLease(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	store := &slowStore{Store: NewMemoryStore(clk), release: make(chan struct{})}
+	l := NewLimiter(store, "test", 100, time.Second, WithBatch(10), WithClock(clk))
+
+	var (
+		wg   sync.WaitGroup
+		done int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := l.Allow(); err == nil {
+				atomic.AddInt32(&done, 1)
+			}
+		}()
+	}
+	// the lock is not held by the lease in flight
+	for atomic.LoadInt32(&store.leases) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	l.mu.Lock()
+	l.mu.Unlock()
+	close(store.release)
+	wg.Wait()
+	// the waiters are served by the tokens of a single lease
+	assert.Equal(t, int32(10), done)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&store.leases))
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Unix(0, 0))
+	store := NewMemoryStore(clk)
+	v, err := store.Incr(ctx, "a", 2, time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), v)
+	swapped, err := store.CompareAndSwap(ctx, "a", 1, 5, time.Second)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+	swapped, err = store.CompareAndSwap(ctx, "a", 2, 5, time.Second)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+
+	// the ttl is not extended by updates
+	clk.Advance(time.Second)
+	v, _ = store.Incr(ctx, "a", 1, time.Second)
+	assert.Equal(t, int64(1), v)
+
+	clk.Advance(2 * time.Second)
+	_, _ = store.Incr(ctx, "b", 1, time.Second)
+	assert.Len(t, store.counters, 1)
+}
+
+func TestTCPStore(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	srv := NewServer(NewMemoryStore(nil))
+	go srv.Serve(lis)
+	defer srv.Close()
+
+	var (
+		wg    sync.WaitGroup
+		count int64
+	)
+	for i := 0; i < 4; i++ {
+		store := NewTCPStore(lis.Addr().String())
+		defer store.Close()
+		l := NewLimiter(store, "test \"quoted\"", 100, time.Hour, WithBatch(7), WithTimeout(time.Second))
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				atomic.AddInt64(&count, int64(allowed(l, 50)))
+			}()
+		}
+	}
+	wg.Wait()
+	assert.Equal(t, int64(100), count)
+
+	store := NewTCPStore(lis.Addr().String())
+	defer store.Close()
+	_, err = store.call(context.Background(), "UNKNOWN\n")
+	assert.NotNil(t, err)
+	// the connection is still usable after an error reply
+	v, err := store.Incr(context.Background(), "k", 3, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), v)
+}
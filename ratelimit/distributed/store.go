@@ -0,0 +1,94 @@
+package distributed
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+)
+
+// Store is a shared storage of counters, a counter which does not exist has the value 0.
+// Implementations must be safe for concurrent use by multiple processes.
+type Store interface {
+	// Incr atomically adds delta to the counter of key and returns the new value,
+	// the ttl of the counter is set if it is created by this call.
+	Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	// CompareAndSwap atomically sets the counter of key to new if its value is old,
+	// the ttl of the counter is set if it is created by this call.
+	CompareAndSwap(ctx context.Context, key string, old, new int64, ttl time.Duration) (bool, error)
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore is a Store in the memory of the process, it is useful in tests
+// and to share the counters between the limiters of a single process.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+	clock     clock.Clock
+}
+
+// counter is a counter of the memory store.
+type counter struct {
+	value    int64
+	deadline time.Time
+}
+
+// NewMemoryStore returns a memory store which expires the counters by the given clock,
+// nil means the wall clock.
+func NewMemoryStore(c clock.Clock) *MemoryStore {
+	c = clock.OrNew(c)
+	return &MemoryStore{
+		counters:  make(map[string]*counter),
+		lastSweep: c.Now(),
+		clock:     c,
+	}
+}
+
+// Incr atomically adds delta to the counter of key and returns the new value.
+func (s *MemoryStore) Incr(_ context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.load(key, ttl)
+	c.value += delta
+	return c.value, nil
+}
+
+// CompareAndSwap atomically sets the counter of key to new if its value is old.
+func (s *MemoryStore) CompareAndSwap(_ context.Context, key string, old, new int64, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.load(key, ttl)
+	if c.value != old {
+		return false, nil
+	}
+	c.value = new
+	return true, nil
+}
+
+// load must be called with the lock held.
+func (s *MemoryStore) load(key string, ttl time.Duration) *counter {
+	now := s.clock.Now()
+	s.sweep(now)
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.deadline) {
+		c = &counter{deadline: now.Add(ttl)}
+		s.counters[key] = c
+	}
+	return c
+}
+
+// sweep removes the expired counters at most once per second.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Second {
+		return
+	}
+	s.lastSweep = now
+	for key, c := range s.counters {
+		if !now.Before(c.deadline) {
+			delete(s.counters, key)
+		}
+	}
+}
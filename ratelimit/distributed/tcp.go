@@ -0,0 +1,215 @@
+package distributed
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The TCP store is a reference Store served over a line based text protocol,
+// it is meant as a local stand-in for a real shared store such as Redis.
+//
+//	INCR <quoted key> <delta> <ttl in nanoseconds>       -> OK <value>
+//	CAS <quoted key> <old> <new> <ttl in nanoseconds>    -> OK <1 or 0>
+//
+// Errors are replied as ERR <message>.
+
+var _ Store = (*TCPStore)(nil)
+
+// Server serves a Store over TCP.
+type Server struct {
+	store Store
+
+	mu    sync.Mutex
+	lis   net.Listener
+	conns map[net.Conn]struct{}
+	done  bool
+}
+
+// NewServer returns a server of the given store.
+func NewServer(store Store) *Server {
+	return &Server{
+		store: store,
+		conns: make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts connections on the listener until the server is closed.
+func (s *Server) Serve(lis net.Listener) error {
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return net.ErrClosed
+	}
+	s.lis = lis
+	s.mu.Unlock()
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.done {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		if s.done {
+			s.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+// Close closes the listener and all the connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = true
+	var err error
+	if s.lis != nil {
+		err = s.lis.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+		delete(s.conns, conn)
+	}
+	return err
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	scanner := bufio.NewScanner(conn)
+	w := bufio.NewWriter(conn)
+	for scanner.Scan() {
+		value, err := s.handle(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(w, "ERR %s\n", err)
+		} else {
+			fmt.Fprintf(w, "OK %d\n", value)
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(line string) (int64, error) {
+	var (
+		key        string
+		delta, ttl int64
+		old, new   int64
+		ctx        = context.Background()
+	)
+	cmd, args, _ := strings.Cut(line, " ")
+	switch cmd {
+	case "INCR":
+		if _, err := fmt.Sscanf(args, "%q %d %d", &key, &delta, &ttl); err != nil {
+			return 0, err
+		}
+		return s.store.Incr(ctx, key, delta, time.Duration(ttl))
+	case "CAS":
+		if _, err := fmt.Sscanf(args, "%q %d %d %d", &key, &old, &new, &ttl); err != nil {
+			return 0, err
+		}
+		swapped, err := s.store.CompareAndSwap(ctx, key, old, new, time.Duration(ttl))
+		if err != nil || !swapped {
+			return 0, err
+		}
+		return 1, nil
+	}
+	return 0, fmt.Errorf("unknown command %q", cmd)
+}
+
+// TCPStore is a Store client of the Server, it holds a single connection
+// which is redialed on the next call once broken.
+type TCPStore struct {
+	addr string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewTCPStore returns a store client of the server at the given address.
+func NewTCPStore(addr string) *TCPStore {
+	return &TCPStore{addr: addr}
+}
+
+// Incr atomically adds delta to the counter of key and returns the new value.
+func (s *TCPStore) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	return s.call(ctx, fmt.Sprintf("INCR %q %d %d\n", key, delta, int64(ttl)))
+}
+
+// CompareAndSwap atomically sets the counter of key to new if its value is old.
+func (s *TCPStore) CompareAndSwap(ctx context.Context, key string, old, new int64, ttl time.Duration) (bool, error) {
+	v, err := s.call(ctx, fmt.Sprintf("CAS %q %d %d %d\n", key, old, new, int64(ttl)))
+	return v == 1, err
+}
+
+// Close closes the connection.
+func (s *TCPStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *TCPStore) call(ctx context.Context, req string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", s.addr)
+		if err != nil {
+			return 0, err
+		}
+		s.conn = conn
+		s.r = bufio.NewReader(conn)
+	}
+	deadline, _ := ctx.Deadline()
+	line, err := s.roundTrip(deadline, req)
+	if err != nil {
+		// the connection is out of sync with the server
+		s.conn.Close()
+		s.conn = nil
+		return 0, err
+	}
+	status, value, _ := strings.Cut(strings.TrimSuffix(line, "\n"), " ")
+	if status != "OK" {
+		return 0, errors.New("distributed: store: " + value)
+	}
+	var v int64
+	if _, err := fmt.Sscan(value, &v); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+func (s *TCPStore) roundTrip(deadline time.Time, req string) (string, error) {
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return "", err
+	}
+	if _, err := s.conn.Write([]byte(req)); err != nil {
+		return "", err
+	}
+	return s.r.ReadString('\n')
+}
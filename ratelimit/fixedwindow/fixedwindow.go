@@ -0,0 +1,82 @@
+package fixedwindow
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+var _ ratelimit.Limiter = (*Limiter)(nil)
+
+// Option is a function that configures the Limiter.
+type Option func(*options)
+
+// options of fixed window limiter.
+type options struct {
+	clock clock.Clock
+}
+
+// WithClock with the time source, default is the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// Limiter is a fixed window limiter, it allows limit requests within each
+// window aligned to the window size.
+// NOTE: up to twice the limit may pass around the boundary of two windows,
+// use the sliding window limiters if this matters.
+type Limiter struct {
+	mu    sync.Mutex
+	start time.Time
+	count int64
+
+	limit  int64
+	window time.Duration
+	clock  clock.Clock
+}
+
+// NewLimiter returns a fixed window limiter which allows limit requests per window.
+func NewLimiter(limit int64, window time.Duration, opts ...Option) *Limiter {
+	opt := options{}
+	for _, o := range opts {
+		o(&opt)
+	}
+	return &Limiter{
+		limit:  limit,
+		window: window,
+		clock:  clock.OrNew(opt.clock),
+	}
+}
+
+// Allow checks whether the request is within the limit of the current window.
+func (l *Limiter) Allow() (ratelimit.DoneFunc, error) {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if start := now.Truncate(l.window); !start.Equal(l.start) {
+		l.start = start
+		l.count = 0
+	}
+	if l.count >= l.limit {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	l.count++
+	return done, nil
+}
+
+// Remaining returns the number of requests left in the current window.
+func (l *Limiter) Remaining() int64 {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !now.Truncate(l.window).Equal(l.start) {
+		return l.limit
+	}
+	return l.limit - l.count
+}
+
+func done(ratelimit.DoneInfo) {}
@@ -0,0 +1,35 @@
+package fixedwindow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllow(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLimiter(3, time.Second, WithClock(clk))
+	assert.Equal(t, int64(3), l.Remaining())
+	for i := 0; i < 3; i++ {
+		done, err := l.Allow()
+		assert.Nil(t, err)
+		done(ratelimit.DoneInfo{})
+	}
+	_, err := l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+	assert.Equal(t, int64(0), l.Remaining())
+
+	clk.Advance(999 * time.Millisecond)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	// a new window resets the count
+	clk.Advance(time.Millisecond)
+	assert.Equal(t, int64(3), l.Remaining())
+	_, err = l.Allow()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), l.Remaining())
+}
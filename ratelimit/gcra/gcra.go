@@ -0,0 +1,188 @@
+package gcra
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+var (
+	_ ratelimit.Limiter        = (*Limiter)(nil)
+	_ ratelimit.WaitLimiter    = (*Limiter)(nil)
+	_ ratelimit.ReserveLimiter = (*Limiter)(nil)
+)
+
+// Option is a function that configures the Limiter.
+type Option func(*options)
+
+// options of gcra limiter.
+type options struct {
+	burst int64
+	clock clock.Clock
+}
+
+// WithBurst with the number of requests allowed at once, default is 1.
+func WithBurst(n int64) Option {
+	return func(o *options) {
+		o.burst = n
+	}
+}
+
+// WithClock with the time source, default is the wall clock.
+// NOTE: Wait always sleeps on the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// Limiter is a limiter of the generic cell rate algorithm, it allows limit
+// requests per period evenly spaced, with bursts of up to burst requests.
+//
+// Instead of counting tokens, GCRA tracks the theoretical arrival time (TAT)
+// of the next request. A request is allowed if it does not arrive earlier
+// than TAT minus the burst tolerance, and each allowed request pushes TAT
+// forward by one emission interval.
+//
+// See https://en.wikipedia.org/wiki/Generic_cell_rate_algorithm.
+type Limiter struct {
+	mu sync.Mutex
+	// tat is the theoretical arrival time
+	tat time.Time
+
+	// interval is the emission interval between two requests
+	interval time.Duration
+	// tolerance is the delay variation tolerance of the bursts
+	tolerance time.Duration
+	// rejected defines whether all the requests are rejected, since the limit is not positive
+	rejected bool
+	clock    clock.Clock
+}
+
+// NewLimiter returns a gcra limiter which allows limit requests per period,
+// it rejects all the requests if the limit is not positive.
+func NewLimiter(limit int64, period time.Duration, opts ...Option) *Limiter {
+	opt := options{
+		burst: 1,
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	if opt.burst < 1 {
+		opt.burst = 1
+	}
+	if limit <= 0 {
+		return &Limiter{rejected: true, clock: clock.OrNew(opt.clock)}
+	}
+	interval := time.Duration(int64(period) / limit)
+	return &Limiter{
+		interval:  interval,
+		tolerance: interval * time.Duration(opt.burst-1),
+		clock:     clock.OrNew(opt.clock),
+	}
+}
+
+// Allow checks whether the request conforms to the rate.
+func (l *Limiter) Allow() (ratelimit.DoneFunc, error) {
+	if l.rejected {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tat := l.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	if tat.Sub(now) > l.tolerance {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	l.tat = tat.Add(l.interval)
+	return done, nil
+}
+
+// Reserve returns a reservation of one request, the request conforms
+// to the rate after the delay of the reservation.
+// The reservation is not OK if the limiter rejects all the requests.
+func (l *Limiter) Reserve() ratelimit.Reservation {
+	if l.rejected {
+		return &reservation{limiter: l}
+	}
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tat := l.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	var delay time.Duration
+	if d := tat.Sub(now); d > l.tolerance {
+		delay = d - l.tolerance
+	}
+	l.tat = tat.Add(l.interval)
+	return &reservation{limiter: l, delay: delay, ok: true}
+}
+
+// Wait blocks until the request conforms to the rate or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) (ratelimit.DoneFunc, error) {
+	r := l.Reserve()
+	if !r.OK() {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	delay := r.Delay()
+	if delay == 0 {
+		return done, nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(l.clock.Now()) < delay {
+		r.Cancel()
+		return nil, ratelimit.ErrLimitExceed
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		r.Cancel()
+		return nil, ctx.Err()
+	case <-timer.C:
+		return done, nil
+	}
+}
+
+// cancel gives back one emission interval, it never moves TAT to the past.
+func (l *Limiter) cancel() {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tat = l.tat.Add(-l.interval)
+	if l.tat.Before(now) {
+		l.tat = now
+	}
+}
+
+// reservation is a reservation of the gcra limiter.
+type reservation struct {
+	once    sync.Once
+	limiter *Limiter
+	delay   time.Duration
+	ok      bool
+}
+
+func (r *reservation) OK() bool {
+	return r.ok
+}
+
+func (r *reservation) Delay() time.Duration {
+	return r.delay
+}
+
+func (r *reservation) Cancel() {
+	if !r.ok {
+		return
+	}
+	r.once.Do(r.limiter.cancel)
+}
+
+func done(ratelimit.DoneInfo) {}
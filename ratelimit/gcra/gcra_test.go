@@ -0,0 +1,99 @@
+package gcra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllow(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLimiter(10, time.Second, WithClock(clk))
+	_, err := l.Allow()
+	assert.Nil(t, err)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	clk.Advance(50 * time.Millisecond)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	clk.Advance(50 * time.Millisecond)
+	done, err := l.Allow()
+	assert.Nil(t, err)
+	done(ratelimit.DoneInfo{})
+}
+
+func TestNonPositiveLimit(t *testing.T) {
+	for _, limit := range []int64{0, -1} {
+		l := NewLimiter(limit, time.Second)
+		_, err := l.Allow()
+		assert.Equal(t, ratelimit.ErrLimitExceed, err)
+		r := l.Reserve()
+		assert.False(t, r.OK())
+		r.Cancel()
+		_, err = l.Wait(context.Background())
+		assert.Equal(t, ratelimit.ErrLimitExceed, err)
+	}
+}
+
+func TestAllowBurst(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLimiter(10, time.Second, WithBurst(3), WithClock(clk))
+	for i := 0; i < 3; i++ {
+		_, err := l.Allow()
+		assert.Nil(t, err)
+	}
+	_, err := l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	// one emission interval frees one request
+	clk.Advance(100 * time.Millisecond)
+	_, err = l.Allow()
+	assert.Nil(t, err)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	// an idle period never accumulates more than the burst
+	clk.Advance(time.Minute)
+	for i := 0; i < 3; i++ {
+		_, err = l.Allow()
+		assert.Nil(t, err)
+	}
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+}
+
+func TestReserve(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLimiter(10, time.Second, WithClock(clk))
+	assert.Equal(t, time.Duration(0), l.Reserve().Delay())
+	r := l.Reserve()
+	assert.True(t, r.OK())
+	assert.Equal(t, 100*time.Millisecond, r.Delay())
+	assert.Equal(t, 200*time.Millisecond, l.Reserve().Delay())
+
+	r.Cancel()
+	r.Cancel()
+	assert.Equal(t, 200*time.Millisecond, l.Reserve().Delay())
+}
+
+func TestWait(t *testing.T) {
+	l := NewLimiter(50, time.Second)
+	_, err := l.Wait(context.Background())
+	assert.Nil(t, err)
+
+	start := time.Now()
+	_, err = l.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = l.Wait(ctx)
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+}
@@ -1,71 +1,226 @@
 package key
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/go-kratos/aegis/internal/syncmap"
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/internal/lru"
+	"github.com/go-kratos/aegis/ratelimit"
 	"golang.org/x/time/rate"
 )
 
 // Option is a function that configures the Limiter.
 type Option func(*Limiter)
 
-// WithExpires sets the expiration duration for the limiter.
+// Resolver returns the limit and burst size of the given key.
+type Resolver func(key string) (rate.Limit, int)
+
+// WithExpires sets the expiration duration for the limiter, default is 1 minute.
+// Zero means never expire.
 func WithExpires(d time.Duration) Option {
 	return func(l *Limiter) {
 		l.expires = d
 	}
 }
 
+// WithClock sets the time source for the limiter, default is the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(l *Limiter) {
+		l.clock = c
+	}
+}
+
+// WithMaxKeys sets the maximum number of keys, the least recently used key
+// is evicted once exceeded. Zero means no limit, which is the default.
+func WithMaxKeys(n int) Option {
+	return func(l *Limiter) {
+		l.maxKeys = n
+	}
+}
+
+// WithResolver sets the resolver of the per-key limits, e.g. to give some
+// tenants higher quotas. It is called once when the limiter of a key is created,
+// keys set by SetLimit take precedence over it.
+func WithResolver(r Resolver) Option {
+	return func(l *Limiter) {
+		l.resolver = r
+	}
+}
+
+// Stat is the statistics of the keys.
+type Stat struct {
+	// Keys is the number of live keys.
+	Keys int
+	// Evicted is the number of keys evicted due to the maximum key count.
+	Evicted int64
+	// Expired is the number of keys removed due to the expiration.
+	Expired int64
+}
+
 // Limiter is a rate limiter that allows a certain number of requests per second.
 type Limiter struct {
 	limit    rate.Limit
 	burst    int
 	expires  time.Duration
-	requests syncmap.SyncMap[string, *keyLimiter]
+	maxKeys  int
+	clock    clock.Clock
+	resolver Resolver
+
+	limiters *lru.Cache[*rate.Limiter]
+	// mu serializes the creation of the limiters with the overrides
+	mu        sync.Mutex
+	overrides map[string]quota
+	evicted   int64
+	expired   int64
+
+	closeOnce sync.Once
+	closed    chan struct{}
 }
 
 // NewLimiter creates a new RateLimiter with the given interval and burst size.
+// Close should be called to stop the cleanup of the expired keys once the limiter is no longer used.
 func NewLimiter(limit rate.Limit, burst int, opts ...Option) *Limiter {
 	l := &Limiter{
-		limit:   limit,
-		burst:   burst,
-		expires: time.Minute,
+		limit:     limit,
+		burst:     burst,
+		expires:   time.Minute,
+		overrides: make(map[string]quota),
+		closed:    make(chan struct{}),
 	}
 	for _, o := range opts {
 		o(l)
 	}
-	go l.cleanupExpired()
+	l.clock = clock.OrNew(l.clock)
+	l.limiters = lru.New(lru.Options[*rate.Limiter]{
+		Capacity: l.maxKeys,
+		Expires:  l.expires,
+		Clock:    l.clock,
+		OnEvict: func(_ string, _ *rate.Limiter, reason lru.Reason) {
+			if reason == lru.ReasonExpired {
+				atomic.AddInt64(&l.expired, 1)
+				return
+			}
+			atomic.AddInt64(&l.evicted, 1)
+		},
+	})
+	if l.expires > 0 {
+		go l.cleanupExpired()
+	}
 	return l
 }
 
 // GetLimiter returns a Limiter for the given key.
 func (l *Limiter) GetLimiter(key string) *rate.Limiter {
-	limiter, ok := l.requests.Load(key)
+	if limiter, ok := l.limiters.Get(key); ok {
+		return limiter
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limiters.GetOrCreate(key, func() *rate.Limiter {
+		q := l.resolve(key)
+		return rate.NewLimiter(q.limit, q.burst)
+	})
+}
+
+// Allow checks whether a request of the given key is allowed.
+func (l *Limiter) Allow(key string) (ratelimit.DoneFunc, error) {
+	if !l.GetLimiter(key).AllowN(l.clock.Now(), 1) {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	return done, nil
+}
+
+// Get returns a ratelimit.Limiter bound to the given key.
+func (l *Limiter) Get(key string) ratelimit.Limiter {
+	return &boundLimiter{limiter: l, key: key}
+}
+
+// SetLimit overrides the limit and burst size of the given key,
+// it takes effect immediately if the key is already in use.
+func (l *Limiter) SetLimit(key string, limit rate.Limit, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	q := quota{limit: limit, burst: burst}
+	l.overrides[key] = q
+	l.update(key, q)
+}
+
+// ResetLimit removes the override of the given key set by SetLimit.
+func (l *Limiter) ResetLimit(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.overrides, key)
+	l.update(key, l.resolve(key))
+}
+
+// resolve must be called with the lock held.
+func (l *Limiter) resolve(key string) quota {
+	if q, ok := l.overrides[key]; ok {
+		return q
+	}
+	if l.resolver != nil {
+		limit, burst := l.resolver(key)
+		return quota{limit: limit, burst: burst}
+	}
+	return quota{limit: l.limit, burst: l.burst}
+}
+
+// update must be called with the lock held.
+func (l *Limiter) update(key string, q quota) {
+	limiter, ok := l.limiters.Get(key)
 	if !ok {
-		limiter, ok = l.requests.LoadOrStore(key, &keyLimiter{
-			Limiter: rate.NewLimiter(l.limit, l.burst),
-		})
+		return
 	}
-	limiter.lastAccess = time.Now()
-	return limiter.Limiter
+	now := l.clock.Now()
+	limiter.SetLimitAt(now, q.limit)
+	limiter.SetBurstAt(now, q.burst)
+}
+
+// Stat returns the statistics of the keys.
+func (l *Limiter) Stat() Stat {
+	return Stat{
+		Keys:    l.limiters.Len(),
+		Evicted: atomic.LoadInt64(&l.evicted),
+		Expired: atomic.LoadInt64(&l.expired),
+	}
+}
+
+// Close stops the background cleanup of the expired keys.
+func (l *Limiter) Close() {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
 }
 
 func (l *Limiter) cleanupExpired() {
 	ticker := time.NewTicker(l.expires)
 	defer ticker.Stop()
-	for range ticker.C {
-		l.requests.Range(func(key string, value *keyLimiter) bool {
-			if time.Since(value.lastAccess) > l.expires {
-				l.requests.Delete(key)
-			}
-			return true
-		})
+	for {
+		select {
+		case <-l.closed:
+			return
+		case <-ticker.C:
+			l.limiters.EvictExpired()
+		}
 	}
 }
 
-// keyLimiter is a rate limiter that does not allow any requests.
-type keyLimiter struct {
-	*rate.Limiter
-	lastAccess time.Time
+// quota is the limit and burst size of a key.
+type quota struct {
+	limit rate.Limit
+	burst int
 }
+
+// boundLimiter is a ratelimit.Limiter of a key.
+type boundLimiter struct {
+	limiter *Limiter
+	key     string
+}
+
+func (b *boundLimiter) Allow() (ratelimit.DoneFunc, error) {
+	return b.limiter.Allow(b.key)
+}
+
+func done(ratelimit.DoneInfo) {}
@@ -2,15 +2,20 @@ package key
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
 	"golang.org/x/time/rate"
 )
 
 func BenchmarkLimiter(b *testing.B) {
 	// Create a new rate limiter with a limit of 1 request per second
 	l := NewLimiter(rate.Every(time.Second), 1)
+	defer l.Close()
 
 	for i := 0; i < b.N; i++ {
 		key := fmt.Sprintf("test_key_%d", i)
@@ -24,6 +29,7 @@ func BenchmarkLimiter(b *testing.B) {
 func TestLimiter(t *testing.T) {
 	// Create a new rate limiter with a limit of 1 request per second
 	l := NewLimiter(rate.Every(time.Second), 1)
+	defer l.Close()
 
 	limiter := l.GetLimiter("test_key")
 	// Test that the first request is allowed
@@ -46,20 +52,107 @@ func TestLimiter(t *testing.T) {
 
 	time.Sleep(time.Second)
 	l.GetLimiter("test_ok")
-	l.requests.Range(func(key string, value *keyLimiter) bool {
-		if time.Since(value.lastAccess) > time.Second {
-			return true
+	l.limiters.Range(func(key string, value *rate.Limiter) bool {
+		// the keys are ranged from the most recently used
+		if key != "test_ok" {
+			t.Errorf("Unexpected most recently used key: %s", key)
+		} else if !value.Allow() {
+			t.Error("Expected first request for test_key2 to be allowed")
 		}
-		switch key {
-		case "test_key":
-			t.Errorf("Expected no requests for test_key after waiting, but found one")
-		case "test_ok":
-			if !value.Allow() {
-				t.Error("Expected first request for test_key2 to be allowed")
+		return false
+	})
+}
+
+func TestLimiterOverrides(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLimiter(rate.Every(time.Second), 1, WithClock(clk), WithResolver(func(key string) (rate.Limit, int) {
+		if key == "vip" {
+			return rate.Every(time.Second), 3
+		}
+		return rate.Every(time.Second), 1
+	}))
+	defer l.Close()
+
+	allowed := func(key string) (n int) {
+		for i := 0; i < 10; i++ {
+			if _, err := l.Allow(key); err == nil {
+				n++
 			}
-		default:
-			t.Errorf("Unexpected key found: %s", key)
 		}
-		return true
-	})
+		return n
+	}
+	assert.Equal(t, 1, allowed("normal"))
+	assert.Equal(t, 3, allowed("vip"))
+
+	// update the limit of an existing key
+	l.SetLimit("normal", rate.Every(time.Second), 2)
+	clk.Advance(2 * time.Second)
+	assert.Equal(t, 2, allowed("normal"))
+	// and of a key which is not in use yet
+	l.SetLimit("new", rate.Inf, 0)
+	assert.Equal(t, 10, allowed("new"))
+
+	l.ResetLimit("normal")
+	clk.Advance(2 * time.Second)
+	assert.Equal(t, 1, allowed("normal"))
+
+	vip := l.Get("vip")
+	_, err := vip.Allow()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, allowed("vip"))
+	_, err = vip.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+}
+
+func TestLimiterMaxKeys(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLimiter(rate.Every(time.Second), 1, WithClock(clk), WithMaxKeys(2), WithExpires(time.Minute))
+	defer l.Close()
+
+	l.GetLimiter("a")
+	l.GetLimiter("b")
+	l.GetLimiter("a")
+	// b is the least recently used key
+	l.GetLimiter("c")
+	assert.Equal(t, Stat{Keys: 2, Evicted: 1}, l.Stat())
+	_, ok := l.limiters.Get("b")
+	assert.False(t, ok)
+
+	clk.Advance(30 * time.Second)
+	l.GetLimiter("c")
+	clk.Advance(31 * time.Second)
+	l.limiters.EvictExpired()
+	assert.Equal(t, Stat{Keys: 1, Evicted: 1, Expired: 1}, l.Stat())
+	_, ok = l.limiters.Get("c")
+	assert.True(t, ok)
+}
+
+func TestLimiterNoExpires(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLimiter(rate.Every(time.Second), 1, WithClock(clk), WithMaxKeys(2), WithExpires(0))
+	defer l.Close()
+
+	l.GetLimiter("a")
+	clk.Advance(time.Hour)
+	l.limiters.EvictExpired()
+	assert.Equal(t, Stat{Keys: 1}, l.Stat())
+}
+
+func TestLimiterConcurrent(t *testing.T) {
+	l := NewLimiter(rate.Inf, 0, WithMaxKeys(10), WithExpires(time.Millisecond))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				_, err := l.Allow(fmt.Sprintf("key_%d", (i+j)%20))
+				assert.Nil(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, l.Stat().Keys, 10)
+	l.Close()
+	l.Close()
 }
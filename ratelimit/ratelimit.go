@@ -1,7 +1,9 @@
 package ratelimit
 
 import (
+	"context"
 	"errors"
+	"time"
 )
 
 var (
@@ -22,3 +24,30 @@ type DoneInfo struct {
 type Limiter interface {
 	Allow() (DoneFunc, error)
 }
+
+// WaitLimiter is a rate limiter which queues the request instead of rejecting it.
+type WaitLimiter interface {
+	Limiter
+	// Wait blocks until the request is allowed or ctx is done.
+	// Implementations may return ErrLimitExceed without waiting if
+	// the request can not be allowed before the deadline of ctx.
+	Wait(ctx context.Context) (DoneFunc, error)
+}
+
+// Reservation holds a permission of the limiter to act after a delay.
+type Reservation interface {
+	// OK reports whether the limiter can grant the permission.
+	OK() bool
+	// Delay returns the duration to wait before acting.
+	Delay() time.Duration
+	// Cancel returns the permission to the limiter, it should be called
+	// if the reservation is not going to be acted on.
+	Cancel()
+}
+
+// ReserveLimiter is a rate limiter which supports reservations.
+type ReserveLimiter interface {
+	Limiter
+	// Reserve returns a reservation for one request.
+	Reserve() Reservation
+}
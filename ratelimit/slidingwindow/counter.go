@@ -0,0 +1,69 @@
+package slidingwindow
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+// CounterLimiter is a sliding window counter limiter, it only keeps the counts
+// of the current and the previous fixed windows, and estimates the count of the
+// last window by weighting the previous count with its overlap with the last window.
+// It takes constant memory at the cost of assuming the requests of the previous
+// window were evenly distributed.
+type CounterLimiter struct {
+	mu    sync.Mutex
+	start time.Time
+	prev  int64
+	curr  int64
+
+	limit  int64
+	window time.Duration
+	clock  clock.Clock
+}
+
+// NewCounterLimiter returns a sliding window counter limiter which allows limit requests per window.
+func NewCounterLimiter(limit int64, window time.Duration, opts ...Option) *CounterLimiter {
+	opt := newOptions(opts)
+	return &CounterLimiter{
+		limit:  limit,
+		window: window,
+		clock:  opt.clock,
+	}
+}
+
+// Allow checks whether the request is within the limit of the estimated last window.
+func (l *CounterLimiter) Allow() (ratelimit.DoneFunc, error) {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(now)
+	if l.estimate(now)+1 > float64(l.limit) {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	l.curr++
+	return done, nil
+}
+
+// advance moves the fixed windows forward to now.
+func (l *CounterLimiter) advance(now time.Time) {
+	start := now.Truncate(l.window)
+	if start.Equal(l.start) {
+		return
+	}
+	if start.Sub(l.start) == l.window {
+		l.prev = l.curr
+	} else {
+		l.prev = 0
+	}
+	l.curr = 0
+	l.start = start
+}
+
+// estimate returns the weighted count of the last window.
+func (l *CounterLimiter) estimate(now time.Time) float64 {
+	weight := 1 - float64(now.Sub(l.start))/float64(l.window)
+	return float64(l.prev)*weight + float64(l.curr)
+}
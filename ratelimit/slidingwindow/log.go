@@ -0,0 +1,54 @@
+package slidingwindow
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+// LogLimiter is a sliding window log limiter, it records the time of every
+// allowed request and allows a request only if fewer than limit requests were
+// allowed within the last window. It is exact, but takes memory proportional to limit.
+type LogLimiter struct {
+	mu sync.Mutex
+	// logs is a ring of the times of the last allowed requests,
+	// head is the index of the oldest one once the ring is full.
+	logs []time.Time
+	head int
+
+	window time.Duration
+	clock  clock.Clock
+}
+
+// NewLogLimiter returns a sliding window log limiter which allows limit requests per window,
+// it rejects all the requests if the limit is not positive.
+func NewLogLimiter(limit int, window time.Duration, opts ...Option) *LogLimiter {
+	opt := newOptions(opts)
+	if limit < 0 {
+		limit = 0
+	}
+	return &LogLimiter{
+		logs:   make([]time.Time, 0, limit),
+		window: window,
+		clock:  opt.clock,
+	}
+}
+
+// Allow checks whether the request is within the limit of the last window.
+func (l *LogLimiter) Allow() (ratelimit.DoneFunc, error) {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.logs) < cap(l.logs) {
+		l.logs = append(l.logs, now)
+		return done, nil
+	}
+	if len(l.logs) == 0 || now.Sub(l.logs[l.head]) < l.window {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	l.logs[l.head] = now
+	l.head = (l.head + 1) % len(l.logs)
+	return done, nil
+}
@@ -0,0 +1,37 @@
+package slidingwindow
+
+import (
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+var (
+	_ ratelimit.Limiter = (*LogLimiter)(nil)
+	_ ratelimit.Limiter = (*CounterLimiter)(nil)
+)
+
+// Option is a function that configures the limiters.
+type Option func(*options)
+
+// options of sliding window limiters.
+type options struct {
+	clock clock.Clock
+}
+
+// WithClock with the time source, default is the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+func newOptions(opts []Option) options {
+	opt := options{}
+	for _, o := range opts {
+		o(&opt)
+	}
+	opt.clock = clock.OrNew(opt.clock)
+	return opt
+}
+
+func done(ratelimit.DoneInfo) {}
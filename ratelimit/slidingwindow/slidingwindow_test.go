@@ -0,0 +1,79 @@
+package slidingwindow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogLimiter(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLogLimiter(2, time.Second, WithClock(clk))
+	_, err := l.Allow()
+	assert.Nil(t, err)
+	clk.Advance(500 * time.Millisecond)
+	_, err = l.Allow()
+	assert.Nil(t, err)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	// unlike a fixed window, the boundary of the second does not reset the limit
+	clk.Advance(499 * time.Millisecond)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	// the first request leaves the window
+	clk.Advance(time.Millisecond)
+	_, err = l.Allow()
+	assert.Nil(t, err)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	clk.Advance(500 * time.Millisecond)
+	_, err = l.Allow()
+	assert.Nil(t, err)
+
+	l = NewLogLimiter(0, time.Second, WithClock(clk))
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+	l = NewLogLimiter(-1, time.Second, WithClock(clk))
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+}
+
+func TestCounterLimiter(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewCounterLimiter(4, time.Second, WithClock(clk))
+	for i := 0; i < 4; i++ {
+		_, err := l.Allow()
+		assert.Nil(t, err)
+	}
+	_, err := l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	// 4*0.75 of the previous window is still counted
+	clk.Advance(1250 * time.Millisecond)
+	_, err = l.Allow()
+	assert.Nil(t, err)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	// 4*0.25 + 1
+	clk.Advance(500 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		_, err = l.Allow()
+		assert.Nil(t, err)
+	}
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	// the previous window is dropped after an idle window
+	clk.Advance(2 * time.Second)
+	for i := 0; i < 4; i++ {
+		_, err = l.Allow()
+		assert.Nil(t, err)
+	}
+}
@@ -0,0 +1,134 @@
+package tokenbucket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+	"golang.org/x/time/rate"
+)
+
+var (
+	_ ratelimit.Limiter        = (*Limiter)(nil)
+	_ ratelimit.WaitLimiter    = (*Limiter)(nil)
+	_ ratelimit.ReserveLimiter = (*Limiter)(nil)
+)
+
+// Option is a function that configures the Limiter.
+type Option func(*options)
+
+// options of token bucket limiter.
+type options struct {
+	clock clock.Clock
+}
+
+// WithClock with the time source, default is the wall clock.
+// NOTE: Wait always sleeps on the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// Limiter is a token bucket limiter, the bucket is refilled at limit tokens per
+// second up to burst tokens, and each request consumes one token.
+type Limiter struct {
+	limiter *rate.Limiter
+	clock   clock.Clock
+}
+
+// NewLimiter returns a token bucket limiter.
+func NewLimiter(limit rate.Limit, burst int, opts ...Option) *Limiter {
+	opt := options{}
+	for _, o := range opts {
+		o(&opt)
+	}
+	return &Limiter{
+		limiter: rate.NewLimiter(limit, burst),
+		clock:   clock.OrNew(opt.clock),
+	}
+}
+
+// Allow checks whether a token is available.
+func (l *Limiter) Allow() (ratelimit.DoneFunc, error) {
+	if !l.limiter.AllowN(l.clock.Now(), 1) {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	return done, nil
+}
+
+// Reserve returns a reservation of one token.
+func (l *Limiter) Reserve() ratelimit.Reservation {
+	now := l.clock.Now()
+	return &reservation{
+		r:     l.limiter.ReserveN(now, 1),
+		now:   now,
+		clock: l.clock,
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) (ratelimit.DoneFunc, error) {
+	r := l.Reserve()
+	if !r.OK() {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	delay := r.Delay()
+	if delay == 0 {
+		return done, nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(l.clock.Now()) < delay {
+		r.Cancel()
+		return nil, ratelimit.ErrLimitExceed
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		r.Cancel()
+		return nil, ctx.Err()
+	case <-timer.C:
+		return done, nil
+	}
+}
+
+// SetLimit sets a new limit of the limiter.
+func (l *Limiter) SetLimit(limit rate.Limit) {
+	l.limiter.SetLimitAt(l.clock.Now(), limit)
+}
+
+// SetBurst sets a new burst size of the limiter.
+func (l *Limiter) SetBurst(burst int) {
+	l.limiter.SetBurstAt(l.clock.Now(), burst)
+}
+
+// Tokens returns the number of tokens available.
+func (l *Limiter) Tokens() float64 {
+	return l.limiter.TokensAt(l.clock.Now())
+}
+
+// reservation is a reservation of the token bucket.
+type reservation struct {
+	once  sync.Once
+	r     *rate.Reservation
+	now   time.Time
+	clock clock.Clock
+}
+
+func (r *reservation) OK() bool {
+	return r.r.OK()
+}
+
+func (r *reservation) Delay() time.Duration {
+	return r.r.DelayFrom(r.now)
+}
+
+func (r *reservation) Cancel() {
+	r.once.Do(func() {
+		r.r.CancelAt(r.clock.Now())
+	})
+}
+
+func done(ratelimit.DoneInfo) {}
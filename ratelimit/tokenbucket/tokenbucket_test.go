@@ -0,0 +1,63 @@
+package tokenbucket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestAllow(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLimiter(rate.Every(100*time.Millisecond), 2, WithClock(clk))
+	for i := 0; i < 2; i++ {
+		done, err := l.Allow()
+		assert.Nil(t, err)
+		done(ratelimit.DoneInfo{})
+	}
+	_, err := l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+
+	clk.Advance(100 * time.Millisecond)
+	_, err = l.Allow()
+	assert.Nil(t, err)
+	_, err = l.Allow()
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+}
+
+func TestReserve(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	l := NewLimiter(rate.Every(100*time.Millisecond), 1, WithClock(clk))
+	r := l.Reserve()
+	assert.True(t, r.OK())
+	assert.Equal(t, time.Duration(0), r.Delay())
+
+	r = l.Reserve()
+	assert.True(t, r.OK())
+	assert.Equal(t, 100*time.Millisecond, r.Delay())
+	r.Cancel()
+	r.Cancel()
+
+	r = l.Reserve()
+	assert.Equal(t, 100*time.Millisecond, r.Delay())
+}
+
+func TestWait(t *testing.T) {
+	l := NewLimiter(rate.Every(20*time.Millisecond), 1)
+	_, err := l.Wait(context.Background())
+	assert.Nil(t, err)
+
+	start := time.Now()
+	_, err = l.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = l.Wait(ctx)
+	assert.Equal(t, ratelimit.ErrLimitExceed, err)
+}
@@ -0,0 +1,69 @@
+package simulation
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Distribution is a distribution of the service latency of the backend.
+type Distribution interface {
+	Sample(r *rand.Rand) time.Duration
+}
+
+// DistributionFunc is an adapter to use a function as a Distribution.
+type DistributionFunc func(r *rand.Rand) time.Duration
+
+// Sample calls f(r).
+func (f DistributionFunc) Sample(r *rand.Rand) time.Duration {
+	return f(r)
+}
+
+// Constant returns a distribution of the constant latency.
+func Constant(d time.Duration) Distribution {
+	return DistributionFunc(func(*rand.Rand) time.Duration {
+		return d
+	})
+}
+
+// Uniform returns a uniform distribution in [min, max).
+func Uniform(min, max time.Duration) Distribution {
+	return DistributionFunc(func(r *rand.Rand) time.Duration {
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	})
+}
+
+// Exponential returns an exponential distribution with the mean.
+func Exponential(mean time.Duration) Distribution {
+	return DistributionFunc(func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	})
+}
+
+// LogNormal returns a log-normal distribution with the median and the
+// standard deviation of the logarithm, which has a long tail as the real backends.
+func LogNormal(median time.Duration, sigma float64) Distribution {
+	return DistributionFunc(func(r *rand.Rand) time.Duration {
+		return time.Duration(float64(median) * math.Exp(r.NormFloat64()*sigma))
+	})
+}
+
+// Load is the offered load in requests per second at the elapsed time of the simulation.
+type Load func(elapsed time.Duration) float64
+
+// ConstantLoad returns a constant load.
+func ConstantLoad(rps float64) Load {
+	return func(time.Duration) float64 {
+		return rps
+	}
+}
+
+// SpikeLoad returns a load of base which raises to peak within [start, start+length).
+func SpikeLoad(base, peak float64, start, length time.Duration) Load {
+	return func(elapsed time.Duration) float64 {
+		if elapsed >= start && elapsed < start+length {
+			return peak
+		}
+		return base
+	}
+}
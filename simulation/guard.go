@@ -0,0 +1,55 @@
+package simulation
+
+import (
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/ratelimit"
+)
+
+// Guard is the component under simulation in front of the backend.
+type Guard interface {
+	// Admit checks whether the request is admitted, done is called once the
+	// admitted request is completed with the latency observed by the client.
+	Admit() (done func(latency time.Duration, err error), ok bool)
+}
+
+// Limiter returns a guard of the rate limiter.
+func Limiter(l ratelimit.Limiter) Guard {
+	return limiterGuard{l}
+}
+
+type limiterGuard struct {
+	l ratelimit.Limiter
+}
+
+func (g limiterGuard) Admit() (func(time.Duration, error), bool) {
+	done, err := g.l.Allow()
+	if err != nil {
+		return nil, false
+	}
+	return func(_ time.Duration, err error) {
+		done(ratelimit.DoneInfo{Err: err})
+	}, true
+}
+
+// Breaker returns a guard of the circuit breaker, the latency is reported
+// if the breaker implements circuitbreaker.LatencyCircuitBreaker, and the
+// rejections are reported if it implements circuitbreaker.RejectionMarker.
+func Breaker(cb circuitbreaker.CircuitBreaker) Guard {
+	return breakerGuard{cb}
+}
+
+type breakerGuard struct {
+	cb circuitbreaker.CircuitBreaker
+}
+
+func (g breakerGuard) Admit() (func(time.Duration, error), bool) {
+	if err := g.cb.Allow(); err != nil {
+		circuitbreaker.MarkRejected(g.cb)
+		return nil, false
+	}
+	return func(latency time.Duration, err error) {
+		circuitbreaker.MarkDone(g.cb, latency, err)
+	}, true
+}
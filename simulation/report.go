@@ -0,0 +1,147 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is the result of a simulation.
+type Report struct {
+	// Intervals are the statistics by the report interval.
+	Intervals []*Interval
+	// Total is the statistics of the whole simulation.
+	Total *Interval
+}
+
+// Interval is the statistics of the requests arrived or completed within an interval.
+type Interval struct {
+	// Start is the elapsed time of the simulation at the start of the interval.
+	Start    time.Duration
+	Duration time.Duration
+	// Offered is the number of requests arrived, Admitted and Dropped are the
+	// ones admitted and rejected by the guard.
+	Offered  int64
+	Admitted int64
+	Dropped  int64
+	// Succeeded and Failed are the number of requests completed,
+	// Failed includes the timed out requests.
+	Succeeded int64
+	Failed    int64
+	// CPU is the average cpu usage of the backend in 1/1000.
+	CPU int64
+
+	latencies []time.Duration
+	cpuSum    int64
+	cpuCount  int64
+}
+
+// Goodput returns the number of successful requests per second.
+func (i *Interval) Goodput() float64 {
+	return float64(i.Succeeded) / i.Duration.Seconds()
+}
+
+// DropRate returns the ratio of the offered requests dropped by the guard.
+func (i *Interval) DropRate() float64 {
+	if i.Offered == 0 {
+		return 0
+	}
+	return float64(i.Dropped) / float64(i.Offered)
+}
+
+// Percentile returns the p-th percentile in range [0, 1] of the latency of the
+// completed requests by the nearest rank, e.g. 0.99 for p99.
+func (i *Interval) Percentile(p float64) time.Duration {
+	if len(i.latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(i.latencies)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(i.latencies) {
+		rank = len(i.latencies) - 1
+	}
+	return i.latencies[rank]
+}
+
+func (i *Interval) record(latency time.Duration, err error) {
+	if err != nil {
+		i.Failed++
+	} else {
+		i.Succeeded++
+	}
+	i.latencies = append(i.latencies, latency)
+}
+
+func (i *Interval) cpu(usage int64) {
+	i.cpuSum += usage
+	i.cpuCount++
+}
+
+func (i *Interval) finish() {
+	sort.Slice(i.latencies, func(a, b int) bool {
+		return i.latencies[a] < i.latencies[b]
+	})
+	if i.cpuCount > 0 {
+		i.CPU = i.cpuSum / i.cpuCount
+	}
+}
+
+func newReport(duration, interval time.Duration) *Report {
+	r := &Report{Total: &Interval{Duration: duration}}
+	for start := time.Duration(0); start < duration; start += interval {
+		d := interval
+		if start+d > duration {
+			d = duration - start
+		}
+		r.Intervals = append(r.Intervals, &Interval{Start: start, Duration: d})
+	}
+	return r
+}
+
+// interval returns the interval of the elapsed time.
+func (r *Report) interval(elapsed time.Duration) *Interval {
+	i := int(elapsed / r.Intervals[0].Duration)
+	if i >= len(r.Intervals) {
+		i = len(r.Intervals) - 1
+	}
+	return r.Intervals[i]
+}
+
+func (r *Report) record(elapsed, latency time.Duration, err error) {
+	r.interval(elapsed).record(latency, err)
+	r.Total.record(latency, err)
+}
+
+func (r *Report) finish() {
+	for _, i := range r.Intervals {
+		r.Total.Offered += i.Offered
+		r.Total.Admitted += i.Admitted
+		r.Total.Dropped += i.Dropped
+		r.Total.cpuSum += i.cpuSum
+		r.Total.cpuCount += i.cpuCount
+		i.finish()
+	}
+	r.Total.finish()
+}
+
+// String formats the report as a table.
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%8s %8s %8s %8s %9s %6s %9s %9s %9s\n",
+		"start", "offered", "dropped", "drop%", "goodput", "cpu", "p50", "p90", "p99")
+	for _, i := range append(r.Intervals, r.Total) {
+		start := i.Start.String()
+		if i == r.Total {
+			start = "total"
+		}
+		fmt.Fprintf(&b, "%8s %8d %8d %8.1f %9.1f %6d %9s %9s %9s\n",
+			start, i.Offered, i.Dropped, i.DropRate()*100, i.Goodput(), i.CPU,
+			i.Percentile(0.5).Round(time.Microsecond), i.Percentile(0.9).Round(time.Microsecond),
+			i.Percentile(0.99).Round(time.Microsecond))
+	}
+	return b.String()
+}
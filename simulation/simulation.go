@@ -0,0 +1,342 @@
+// Package simulation is a deterministic discrete event simulation of the
+// traffic through a rate limiter or a circuit breaker to a synthetic backend,
+// driven by a virtual clock, to evaluate their configurations under load.
+package simulation
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+)
+
+var (
+	// ErrBackend is the error of the requests failed by the error rate of the backend.
+	ErrBackend = errors.New("simulation: backend error")
+	// ErrQueueFull is the error of the requests rejected by the full queue of the backend.
+	ErrQueueFull = errors.New("simulation: backend queue full")
+	// ErrTimeout is the error of the requests timed out at the client.
+	ErrTimeout = errors.New("simulation: timeout")
+)
+
+// Backend is a synthetic backend which serves Capacity requests concurrently,
+// the other requests wait in a FIFO queue.
+type Backend struct {
+	// Capacity is the number of requests served concurrently, default is 1.
+	Capacity int
+	// Queue is the maximum number of waiting requests, zero means no limit.
+	Queue int
+	// Latency is the distribution of the service latency excluding the queueing.
+	Latency Distribution
+	// ErrorRate is the probability in [0, 1] that a request fails.
+	ErrorRate float64
+}
+
+// Option is a function that configures the Simulation.
+type Option func(*options)
+
+// options of simulation.
+type options struct {
+	duration    time.Duration
+	interval    time.Duration
+	timeout     time.Duration
+	cpuInterval time.Duration
+	cpuDecay    float64
+	seed        int64
+	load        Load
+	backend     Backend
+}
+
+// WithDuration with the duration of the simulation, default is 1 minute.
+func WithDuration(d time.Duration) Option {
+	return func(o *options) {
+		o.duration = d
+	}
+}
+
+// WithInterval with the interval of the report, default is 1 second.
+func WithInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.interval = d
+	}
+}
+
+// WithTimeout with the client timeout of the requests, zero means no timeout,
+// which is the default. The backend keeps serving the timed out requests.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithCPU with the sampling interval and the decay of the moving average of
+// the cpu usage of the backend, default is 500ms and 0.
+func WithCPU(interval time.Duration, decay float64) Option {
+	return func(o *options) {
+		o.cpuInterval = interval
+		o.cpuDecay = decay
+	}
+}
+
+// WithSeed with the seed of the random numbers, default is 1.
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+	}
+}
+
+// WithLoad with the offered load, the requests arrive as a poisson process.
+// default is 100 requests per second.
+func WithLoad(l Load) Option {
+	return func(o *options) {
+		o.load = l
+	}
+}
+
+// WithBackend with the backend, default is a backend of capacity 10 and
+// a constant latency of 10ms.
+func WithBackend(b Backend) Option {
+	return func(o *options) {
+		o.backend = b
+	}
+}
+
+// Simulation is a simulation of the traffic to a backend through a guard.
+// The guard must use Clock as the time source, and the cpu usage of the
+// backend (the share of the busy capacity) can be injected into the guard by CPU.
+// A simulation runs once, and is not safe for concurrent use.
+type Simulation struct {
+	clock *clock.Fake
+	start time.Time
+	rand  *rand.Rand
+	opts  options
+
+	events eventQueue
+	seq    int64
+
+	// busy is the number of requests in service
+	busy  int
+	queue []*request
+	// busyTime is the integral of busy over time since the last cpu sample
+	busyTime   float64
+	lastChange time.Time
+	cpu        int64
+
+	report *Report
+}
+
+// New returns a simulation.
+func New(opts ...Option) *Simulation {
+	opt := options{
+		duration:    time.Minute,
+		interval:    time.Second,
+		cpuInterval: 500 * time.Millisecond,
+		seed:        1,
+		load:        ConstantLoad(100),
+		backend: Backend{
+			Capacity: 10,
+			Latency:  Constant(10 * time.Millisecond),
+		},
+	}
+	for _, o := range opts {
+		o(&opt)
+	}
+	if opt.backend.Capacity < 1 {
+		opt.backend.Capacity = 1
+	}
+	// NOTE: a fixed start time keeps the simulation deterministic,
+	// the zero unix time is avoided since bbr treats it as unset.
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &Simulation{
+		clock:      clock.NewFake(start),
+		start:      start,
+		rand:       rand.New(rand.NewSource(opt.seed)),
+		opts:       opt,
+		lastChange: start,
+	}
+}
+
+// Clock returns the virtual clock of the simulation.
+func (s *Simulation) Clock() clock.Clock {
+	return s.clock
+}
+
+// CPU returns the cpu usage of the backend in 1/1000, in the same scale as bbr.
+func (s *Simulation) CPU() int64 {
+	return s.cpu
+}
+
+// Run runs the simulation through the guard and returns the report.
+func (s *Simulation) Run(g Guard) *Report {
+	s.report = newReport(s.opts.duration, s.opts.interval)
+	s.schedule(s.start, eventArrival, nil)
+	s.schedule(s.start.Add(s.opts.cpuInterval), eventCPU, nil)
+	end := s.start.Add(s.opts.duration)
+	for s.events.Len() > 0 {
+		e := heap.Pop(&s.events).(*event)
+		if e.at.After(end) {
+			break
+		}
+		s.clock.Set(e.at)
+		switch e.kind {
+		case eventArrival:
+			s.arrive(g)
+		case eventComplete:
+			s.complete(e.req)
+		case eventTimeout:
+			s.finish(e.req, ErrTimeout)
+		case eventCPU:
+			s.sampleCPU()
+		}
+	}
+	s.report.finish()
+	return s.report
+}
+
+func (s *Simulation) elapsed() time.Duration {
+	return s.clock.Now().Sub(s.start)
+}
+
+func (s *Simulation) arrive(g Guard) {
+	now := s.clock.Now()
+	// schedule the next arrival
+	if rps := s.opts.load(s.elapsed()); rps > 0 {
+		s.schedule(now.Add(time.Duration(s.rand.ExpFloat64()/rps*float64(time.Second))), eventArrival, nil)
+	} else {
+		s.schedule(now.Add(10*time.Millisecond), eventArrival, nil)
+	}
+	interval := s.report.interval(s.elapsed())
+	interval.Offered++
+	done, ok := g.Admit()
+	if !ok {
+		interval.Dropped++
+		return
+	}
+	interval.Admitted++
+	req := &request{arrival: now, done: done}
+	if s.opts.timeout > 0 {
+		s.schedule(now.Add(s.opts.timeout), eventTimeout, req)
+	}
+	if s.busy < s.opts.backend.Capacity {
+		s.serve(req)
+		return
+	}
+	if s.opts.backend.Queue > 0 && len(s.queue) >= s.opts.backend.Queue {
+		s.finish(req, ErrQueueFull)
+		return
+	}
+	s.queue = append(s.queue, req)
+}
+
+// serve starts serving the request.
+func (s *Simulation) serve(req *request) {
+	s.setBusy(s.busy + 1)
+	var latency time.Duration
+	if s.opts.backend.Latency != nil {
+		latency = s.opts.backend.Latency.Sample(s.rand)
+	}
+	if latency < 0 {
+		latency = 0
+	}
+	req.failed = s.rand.Float64() < s.opts.backend.ErrorRate
+	s.schedule(s.clock.Now().Add(latency), eventComplete, req)
+}
+
+func (s *Simulation) complete(req *request) {
+	s.setBusy(s.busy - 1)
+	if len(s.queue) > 0 {
+		next := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.serve(next)
+	}
+	var err error
+	if req.failed {
+		err = ErrBackend
+	}
+	s.finish(req, err)
+}
+
+// finish reports the request to the guard once, either on completion or timeout.
+func (s *Simulation) finish(req *request, err error) {
+	if req.finished {
+		return
+	}
+	req.finished = true
+	latency := s.clock.Now().Sub(req.arrival)
+	s.report.record(s.elapsed(), latency, err)
+	req.done(latency, err)
+}
+
+func (s *Simulation) setBusy(busy int) {
+	now := s.clock.Now()
+	s.busyTime += float64(s.busy) * float64(now.Sub(s.lastChange))
+	s.lastChange = now
+	s.busy = busy
+}
+
+func (s *Simulation) sampleCPU() {
+	s.setBusy(s.busy)
+	usage := s.busyTime / float64(s.opts.cpuInterval) / float64(s.opts.backend.Capacity) * 1000
+	s.busyTime = 0
+	s.cpu = int64(math.Round(float64(s.cpu)*s.opts.cpuDecay + usage*(1-s.opts.cpuDecay)))
+	s.report.interval(s.elapsed()).cpu(s.cpu)
+	s.schedule(s.clock.Now().Add(s.opts.cpuInterval), eventCPU, nil)
+}
+
+func (s *Simulation) schedule(at time.Time, kind eventKind, req *request) {
+	s.seq++
+	heap.Push(&s.events, &event{at: at, seq: s.seq, kind: kind, req: req})
+}
+
+// request is a request admitted by the guard.
+type request struct {
+	arrival  time.Time
+	done     func(time.Duration, error)
+	failed   bool
+	finished bool
+}
+
+type eventKind int
+
+const (
+	eventArrival eventKind = iota
+	eventComplete
+	eventTimeout
+	eventCPU
+)
+
+// event is a scheduled event, the events at the same time are ordered by seq.
+type event struct {
+	at   time.Time
+	seq  int64
+	kind eventKind
+	req  *request
+}
+
+// eventQueue is a min-heap of the events.
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return e
+}
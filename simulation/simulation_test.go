@@ -0,0 +1,118 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/circuitbreaker/consecutive"
+	"github.com/go-kratos/aegis/circuitbreaker/sre"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/go-kratos/aegis/ratelimit/bbr"
+	"github.com/stretchr/testify/assert"
+)
+
+type allowAll struct{}
+
+func (allowAll) Allow() (ratelimit.DoneFunc, error) {
+	return func(ratelimit.DoneInfo) {}, nil
+}
+
+func spike(opts ...Option) *Simulation {
+	return New(append([]Option{
+		WithDuration(30 * time.Second),
+		WithTimeout(500 * time.Millisecond),
+		// the capacity is 1000 requests per second
+		WithBackend(Backend{Capacity: 10, Latency: Exponential(10 * time.Millisecond)}),
+		WithLoad(SpikeLoad(500, 3000, 10*time.Second, 10*time.Second)),
+	}, opts...)...)
+}
+
+func TestSimulation(t *testing.T) {
+	s := New(WithDuration(10*time.Second), WithLoad(ConstantLoad(100)), WithBackend(Backend{
+		Capacity:  10,
+		Latency:   Constant(10 * time.Millisecond),
+		ErrorRate: 0.1,
+	}))
+	report := s.Run(Limiter(allowAll{}))
+	assert.Len(t, report.Intervals, 10)
+	total := report.Total
+	assert.InDelta(t, 1000, total.Offered, 100)
+	assert.Equal(t, total.Offered, total.Admitted)
+	assert.Equal(t, int64(0), total.Dropped)
+	assert.InDelta(t, 0.1, float64(total.Failed)/float64(total.Offered), 0.03)
+	assert.Equal(t, 10*time.Millisecond, total.Percentile(0.99))
+	// 100 rps * 10ms / 10
+	assert.InDelta(t, 100, total.CPU, 20)
+	assert.NotEmpty(t, report.String())
+}
+
+func TestSimulationDeterministic(t *testing.T) {
+	r1 := spike().Run(Limiter(allowAll{}))
+	r2 := spike().Run(Limiter(allowAll{}))
+	assert.Equal(t, r1.String(), r2.String())
+	assert.NotEqual(t, r1.String(), spike(WithSeed(2)).Run(Limiter(allowAll{})).String())
+}
+
+func TestSimulationDeterministicSRE(t *testing.T) {
+	run := func(seed uint64) *Report {
+		s := spike()
+		cb := sre.NewBreaker(sre.WithClock(s.Clock()), sre.WithSeed(seed))
+		return s.Run(Breaker(cb))
+	}
+	r1, r2 := run(1), run(1)
+	assert.Greater(t, r1.Total.Dropped, int64(0))
+	assert.Equal(t, r1.String(), r2.String())
+	assert.NotEqual(t, r1.String(), run(2).String())
+}
+
+// rejectAll is a breaker rejecting all the requests.
+type rejectAll struct {
+	circuitbreaker.CircuitBreaker
+	rejected int
+}
+
+func (*rejectAll) Allow() error { return circuitbreaker.ErrNotAllowed }
+
+func (b *rejectAll) MarkRejected() { b.rejected++ }
+
+func TestSimulationBreakerRejected(t *testing.T) {
+	cb := &rejectAll{}
+	report := New(WithDuration(time.Second), WithLoad(ConstantLoad(100))).Run(Breaker(cb))
+	assert.Equal(t, report.Total.Dropped, int64(cb.rejected))
+	assert.Greater(t, cb.rejected, 0)
+}
+
+func TestSimulationBBR(t *testing.T) {
+	unguarded := spike().Run(Limiter(allowAll{}))
+
+	s := spike()
+	limiter := bbr.NewLimiter(
+		bbr.WithClock(s.Clock()),
+		bbr.WithSignal(bbr.SignalFunc(func() bool { return s.CPU() >= 800 })),
+	)
+	guarded := s.Run(Limiter(limiter))
+
+	// the backend collapses without shedding, since all the requests time out
+	overload := unguarded.Intervals[15]
+	assert.Less(t, overload.Goodput(), 100.0)
+	assert.Equal(t, int64(0), overload.Dropped)
+
+	overload = guarded.Intervals[15]
+	assert.Greater(t, overload.Goodput(), 500.0)
+	assert.Greater(t, overload.DropRate(), 0.5)
+	assert.Less(t, overload.Percentile(0.99), 500*time.Millisecond)
+	// the traffic is not dropped after the spike
+	assert.Equal(t, int64(0), guarded.Intervals[25].Dropped)
+}
+
+func TestSimulationBreaker(t *testing.T) {
+	s := New(
+		WithDuration(10*time.Second),
+		WithBackend(Backend{Capacity: 10, Latency: Constant(time.Millisecond), ErrorRate: 1}),
+	)
+	cb := consecutive.NewBreaker(consecutive.WithClock(s.Clock()), consecutive.WithOpenWait(time.Second))
+	report := s.Run(Breaker(cb))
+	assert.Greater(t, report.Total.DropRate(), 0.9)
+	assert.Equal(t, int64(0), report.Total.Succeeded)
+}
@@ -0,0 +1,158 @@
+package sysstat
+
+import (
+	"errors"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CPUStat is the cpu statistics of the cgroup.
+type CPUStat struct {
+	// Usage is the total cpu time consumed.
+	Usage time.Duration
+	// Limit is the number of cpus the cgroup is allowed to use by the cfs quota,
+	// it is zero if no quota is set.
+	Limit float64
+	// CPUs is the cpus the cgroup is allowed to run on.
+	CPUs []uint64
+	// Throttling is the cfs throttling statistics.
+	Throttling Throttling
+}
+
+// Throttling is the cfs throttling statistics of the cgroup.
+type Throttling struct {
+	// Periods is the number of enforcement periods elapsed.
+	Periods uint64
+	// Throttled is the number of periods the cgroup has been throttled.
+	Throttled uint64
+	// ThrottledTime is the total time the cgroup has been throttled.
+	ThrottledTime time.Duration
+}
+
+// CPU reads the cpu statistics of the cgroup of the process.
+func (r *Reader) CPU() (*CPUStat, error) {
+	cg, err := r.loadCgroup()
+	if err != nil {
+		return nil, err
+	}
+	return cg.cpu(r)
+}
+
+// cgroupv1 is the cgroup v1 with the directories by controller.
+type cgroupv1 struct {
+	dirs map[string]string
+}
+
+func (c *cgroupv1) cpu(r *Reader) (*CPUStat, error) {
+	usage, err := r.readUint(path.Join(c.dirs["cpuacct"], "cpuacct.usage"))
+	if err != nil {
+		return nil, err
+	}
+	stat := &CPUStat{Usage: time.Duration(usage)}
+	if stat.Limit, err = c.cpuLimit(r); err != nil && err != ErrNoLimit {
+		return nil, err
+	}
+	cpus, err := r.readString(path.Join(c.dirs["cpuset"], "cpuset.cpus"))
+	if err != nil {
+		return nil, err
+	}
+	if stat.CPUs, err = parseUintList(cpus); err != nil {
+		return nil, err
+	}
+	kv, err := r.readKeyValues(path.Join(c.dirs["cpu"], "cpu.stat"))
+	if err != nil {
+		return nil, err
+	}
+	stat.Throttling = Throttling{
+		Periods:       kv["nr_periods"],
+		Throttled:     kv["nr_throttled"],
+		ThrottledTime: time.Duration(kv["throttled_time"]),
+	}
+	return stat, nil
+}
+
+func (c *cgroupv1) cpuLimit(r *Reader) (float64, error) {
+	s, err := r.readString(path.Join(c.dirs["cpu"], "cpu.cfs_quota_us"))
+	if err != nil {
+		return 0, err
+	}
+	quota, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	// -1 means no quota, the kernel rejects the other negative values
+	if quota < 0 {
+		return 0, ErrNoLimit
+	}
+	period, err := r.readUint(path.Join(c.dirs["cpu"], "cpu.cfs_period_us"))
+	if err != nil {
+		return 0, err
+	}
+	return cfsLimit(uint64(quota), period)
+}
+
+// cgroupv2 is the unified cgroup v2 at the directory.
+type cgroupv2 struct {
+	dir string
+}
+
+func (c *cgroupv2) cpu(r *Reader) (*CPUStat, error) {
+	kv, err := r.readKeyValues(path.Join(c.dir, "cpu.stat"))
+	if err != nil {
+		return nil, err
+	}
+	stat := &CPUStat{
+		Usage: time.Duration(kv["usage_usec"]) * time.Microsecond,
+		Throttling: Throttling{
+			Periods:       kv["nr_periods"],
+			Throttled:     kv["nr_throttled"],
+			ThrottledTime: time.Duration(kv["throttled_usec"]) * time.Microsecond,
+		},
+	}
+	if stat.Limit, err = c.cpuLimit(r); err != nil && err != ErrNoLimit {
+		return nil, err
+	}
+	cpus, err := r.readString(path.Join(c.dir, "cpuset.cpus.effective"))
+	if err != nil {
+		return nil, err
+	}
+	if stat.CPUs, err = parseUintList(cpus); err != nil {
+		return nil, err
+	}
+	return stat, nil
+}
+
+func (c *cgroupv2) cpuLimit(r *Reader) (float64, error) {
+	s, err := r.readString(path.Join(c.dir, "cpu.max"))
+	if err != nil {
+		return 0, err
+	}
+	// $MAX $PERIOD
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, errors.New("sysstat: invalid cpu.max " + s)
+	}
+	if fields[0] == "max" {
+		return 0, ErrNoLimit
+	}
+	quota, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	period, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return cfsLimit(quota, period)
+}
+
+// cfsLimit returns the number of cpus of the cfs quota and period,
+// both cgroup versions treat a zero period as an invalid file.
+func cfsLimit(quota, period uint64) (float64, error) {
+	if period == 0 {
+		return 0, errors.New("sysstat: zero cfs period")
+	}
+	return float64(quota) / float64(period), nil
+}
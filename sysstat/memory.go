@@ -0,0 +1,58 @@
+package sysstat
+
+import "path"
+
+// unlimitedV1 is the memory limit of cgroup v1 above which no limit is set,
+// the unlimited value is the max int64 rounded down to the page size.
+const unlimitedV1 = 1 << 62
+
+// MemoryStat is the memory statistics of the cgroup.
+type MemoryStat struct {
+	// Usage is the bytes of memory used.
+	Usage uint64
+	// Limit is the bytes of memory the cgroup is allowed to use,
+	// it is zero if no limit is set.
+	Limit uint64
+}
+
+// Memory reads the memory statistics of the cgroup of the process.
+func (r *Reader) Memory() (*MemoryStat, error) {
+	cg, err := r.loadCgroup()
+	if err != nil {
+		return nil, err
+	}
+	return cg.memory(r)
+}
+
+func (c *cgroupv1) memory(r *Reader) (*MemoryStat, error) {
+	usage, err := r.readUint(path.Join(c.dirs["memory"], "memory.usage_in_bytes"))
+	if err != nil {
+		return nil, err
+	}
+	limit, err := r.readUint(path.Join(c.dirs["memory"], "memory.limit_in_bytes"))
+	if err != nil {
+		return nil, err
+	}
+	if limit >= unlimitedV1 {
+		limit = 0
+	}
+	return &MemoryStat{Usage: usage, Limit: limit}, nil
+}
+
+func (c *cgroupv2) memory(r *Reader) (*MemoryStat, error) {
+	usage, err := r.readUint(path.Join(c.dir, "memory.current"))
+	if err != nil {
+		return nil, err
+	}
+	stat := &MemoryStat{Usage: usage}
+	s, err := r.readString(path.Join(c.dir, "memory.max"))
+	if err != nil {
+		return nil, err
+	}
+	if s != "max" {
+		if stat.Limit, err = r.readUint(path.Join(c.dir, "memory.max")); err != nil {
+			return nil, err
+		}
+	}
+	return stat, nil
+}
@@ -0,0 +1,91 @@
+package sysstat
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resource is a resource of the pressure stall information.
+type Resource string
+
+const (
+	// CPU is the pressure of the cpu.
+	CPU Resource = "cpu"
+	// Memory is the pressure of the memory.
+	Memory Resource = "memory"
+	// IO is the pressure of the io.
+	IO Resource = "io"
+)
+
+// Pressure is the pressure stall information of a resource.
+//
+// See https://docs.kernel.org/accounting/psi.html.
+type Pressure struct {
+	// Some is the share of time some tasks are stalled on the resource.
+	Some PressureStat
+	// Full is the share of time all non-idle tasks are stalled on the resource
+	// at the same time, it is zero for the cpu on old kernels.
+	Full PressureStat
+}
+
+// PressureStat is the stall percentages over 10, 60 and 300 seconds
+// and the total stall time.
+type PressureStat struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	Total  time.Duration
+}
+
+// Pressure reads the system-wide pressure stall information of the resource,
+// the file looks like:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func (r *Reader) Pressure(resource Resource) (*Pressure, error) {
+	s, err := r.readString("proc/pressure/" + string(resource))
+	if err != nil {
+		return nil, err
+	}
+	p := &Pressure{}
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var stat *PressureStat
+		switch fields[0] {
+		case "some":
+			stat = &p.Some
+		case "full":
+			stat = &p.Full
+		default:
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			if key == "total" {
+				total, err := strconv.ParseUint(value, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				stat.Total = time.Duration(total) * time.Microsecond
+				continue
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, err
+			}
+			switch key {
+			case "avg10":
+				stat.Avg10 = v
+			case "avg60":
+				stat.Avg60 = v
+			case "avg300":
+				stat.Avg300 = v
+			}
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,169 @@
+// Package sysstat reads the system resource statistics of the process from
+// the Linux cgroup (v1 and v2) and pressure stall information files.
+package sysstat
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrNoLimit is returned when no limit of the resource is set.
+	ErrNoLimit = errors.New("sysstat: no limit")
+	// ErrNoCgroup is returned when the cgroup of the process is not found.
+	ErrNoCgroup = errors.New("sysstat: no cgroup")
+)
+
+const cgroupRootDir = "sys/fs/cgroup"
+
+var (
+	defaultReader     *Reader
+	defaultReaderOnce sync.Once
+)
+
+// Default returns the reader rooted at the root directory of the host.
+func Default() *Reader {
+	defaultReaderOnce.Do(func() {
+		defaultReader = New(os.DirFS("/"))
+	})
+	return defaultReader
+}
+
+// Reader reads the statistics from a filesystem rooted at the root directory,
+// e.g. os.DirFS("/") or a fixture directory in tests.
+// The cgroup version is detected on the first read.
+type Reader struct {
+	fsys fs.FS
+
+	once   sync.Once
+	cgroup cgroup
+	err    error
+}
+
+// New returns a reader of the filesystem.
+func New(fsys fs.FS) *Reader {
+	return &Reader{fsys: fsys}
+}
+
+// cgroup is the files of a cgroup version.
+type cgroup interface {
+	cpu(r *Reader) (*CPUStat, error)
+	memory(r *Reader) (*MemoryStat, error)
+}
+
+func (r *Reader) loadCgroup() (cgroup, error) {
+	r.once.Do(func() {
+		r.cgroup, r.err = r.detectCgroup()
+	})
+	return r.cgroup, r.err
+}
+
+func (r *Reader) detectCgroup() (cgroup, error) {
+	if _, err := fs.Stat(r.fsys, path.Join(cgroupRootDir, "cgroup.controllers")); err == nil {
+		return &cgroupv2{dir: cgroupRootDir}, nil
+	}
+	f, err := r.fsys.Open("proc/self/cgroup")
+	if err != nil {
+		return nil, ErrNoCgroup
+	}
+	defer f.Close()
+	// hierarchy-ID:controller-list:cgroup-path, the cgroup of a container is
+	// mounted at the root of each controller.
+	dirs := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		cols := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 3)
+		if len(cols) != 3 || cols[1] == "" {
+			continue
+		}
+		dir := path.Join(cgroupRootDir, cols[1])
+		if cols[2] != "/" {
+			if _, err := fs.Stat(r.fsys, path.Join(dir, cols[2])); err == nil {
+				dir = path.Join(dir, cols[2])
+			}
+		}
+		for _, controller := range strings.Split(cols[1], ",") {
+			dirs[controller] = dir
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return nil, ErrNoCgroup
+	}
+	return &cgroupv1{dirs: dirs}, nil
+}
+
+// readString reads the trimmed content of the file.
+func (r *Reader) readString(name string) (string, error) {
+	data, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readUint reads the file of a single unsigned integer.
+func (r *Reader) readUint(name string) (uint64, error) {
+	s, err := r.readString(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// readKeyValues reads the file of "key value" lines, e.g. cpu.stat.
+func (r *Reader) readKeyValues(name string) (map[string]uint64, error) {
+	s, err := r.readString(name)
+	if err != nil {
+		return nil, err
+	}
+	kv := make(map[string]uint64)
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		kv[fields[0]] = v
+	}
+	return kv, nil
+}
+
+// parseUintList parses the list of cpus or memory nodes, e.g. "0-2,4,7-8".
+func parseUintList(s string) ([]uint64, error) {
+	var list []uint64
+	if s == "" {
+		return list, nil
+	}
+	for _, r := range strings.Split(s, ",") {
+		lo, hi, ok := strings.Cut(r, "-")
+		start, err := strconv.ParseUint(lo, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if ok {
+			if end, err = strconv.ParseUint(hi, 10, 64); err != nil {
+				return nil, err
+			}
+			if end < start {
+				return nil, errors.New("sysstat: invalid list " + s)
+			}
+		}
+		for i := start; i <= end; i++ {
+			list = append(list, i)
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,159 @@
+package sysstat
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func file(data string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(data)}
+}
+
+func TestCgroupv1(t *testing.T) {
+	r := New(fstest.MapFS{
+		"proc/self/cgroup": file("12:cpuset:/\n11:cpu,cpuacct:/docker/abc\n10:memory:/\n0::/\n"),
+		// the cgroup of the host
+		"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpuacct.usage":     file("1500000000\n"),
+		"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_quota_us":  file("150000\n"),
+		"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_period_us": file("100000\n"),
+		"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.stat":          file("nr_periods 10\nnr_throttled 3\nthrottled_time 2000000\n"),
+		"sys/fs/cgroup/cpuset/cpuset.cpus":                       file("0-1,3\n"),
+		"sys/fs/cgroup/memory/memory.usage_in_bytes":             file("1024\n"),
+		"sys/fs/cgroup/memory/memory.limit_in_bytes":             file("9223372036854771712\n"),
+	})
+	cpu, err := r.CPU()
+	assert.Nil(t, err)
+	assert.Equal(t, &CPUStat{
+		Usage: 1500 * time.Millisecond,
+		Limit: 1.5,
+		CPUs:  []uint64{0, 1, 3},
+		Throttling: Throttling{
+			Periods:       10,
+			Throttled:     3,
+			ThrottledTime: 2 * time.Millisecond,
+		},
+	}, cpu)
+
+	mem, err := r.Memory()
+	assert.Nil(t, err)
+	assert.Equal(t, &MemoryStat{Usage: 1024}, mem)
+}
+
+func TestCgroupv2(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sys/fs/cgroup/cgroup.controllers":    file("cpuset cpu io memory\n"),
+		"sys/fs/cgroup/cpu.stat":              file("usage_usec 2000\nuser_usec 1000\nsystem_usec 1000\nnr_periods 5\nnr_throttled 1\nthrottled_usec 300\n"),
+		"sys/fs/cgroup/cpu.max":               file("max 100000\n"),
+		"sys/fs/cgroup/cpuset.cpus.effective": file("0-3\n"),
+		"sys/fs/cgroup/memory.current":        file("4096\n"),
+		"sys/fs/cgroup/memory.max":            file("8192\n"),
+		"proc/self/cgroup":                    file("0::/\n"),
+		"proc/pressure/memory":                file("some avg10=1.50 avg60=0.25 avg300=0.00 total=1000\nfull avg10=0.50 avg60=0.00 avg300=0.00 total=10\n"),
+		"proc/pressure/cpu":                   file("some avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"),
+	}
+	r := New(fsys)
+	cpu, err := r.CPU()
+	assert.Nil(t, err)
+	assert.Equal(t, &CPUStat{
+		Usage: 2 * time.Millisecond,
+		CPUs:  []uint64{0, 1, 2, 3},
+		Throttling: Throttling{
+			Periods:       5,
+			Throttled:     1,
+			ThrottledTime: 300 * time.Microsecond,
+		},
+	}, cpu)
+
+	fsys["sys/fs/cgroup/cpu.max"] = file("50000 100000\n")
+	cpu, err = r.CPU()
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, cpu.Limit)
+
+	mem, err := r.Memory()
+	assert.Nil(t, err)
+	assert.Equal(t, &MemoryStat{Usage: 4096, Limit: 8192}, mem)
+	fsys["sys/fs/cgroup/memory.max"] = file("max\n")
+	mem, err = r.Memory()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), mem.Limit)
+
+	p, err := r.Pressure(Memory)
+	assert.Nil(t, err)
+	assert.Equal(t, &Pressure{
+		Some: PressureStat{Avg10: 1.5, Avg60: 0.25, Total: time.Millisecond},
+		Full: PressureStat{Avg10: 0.5, Total: 10 * time.Microsecond},
+	}, p)
+	p, err = r.Pressure(CPU)
+	assert.Nil(t, err)
+	assert.Equal(t, &Pressure{}, p)
+	_, err = r.Pressure(IO)
+	assert.NotNil(t, err)
+}
+
+func TestNoCgroup(t *testing.T) {
+	r := New(fstest.MapFS{})
+	_, err := r.CPU()
+	assert.Equal(t, ErrNoCgroup, err)
+	_, err = r.Memory()
+	assert.Equal(t, ErrNoCgroup, err)
+}
+
+func TestParseUintList(t *testing.T) {
+	list, err := parseUintList("0-2,4,7-8")
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{0, 1, 2, 4, 7, 8}, list)
+	_, err = parseUintList("3-1")
+	assert.NotNil(t, err)
+	_, err = parseUintList("a")
+	assert.NotNil(t, err)
+}
+
+func TestCPULimit(t *testing.T) {
+	v1 := func(quota, period string) *Reader {
+		return New(fstest.MapFS{
+			"proc/self/cgroup":                            file("1:cpu,cpuacct:/\n2:cpuset:/\n"),
+			"sys/fs/cgroup/cpu,cpuacct/cpuacct.usage":     file("0\n"),
+			"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  file(quota),
+			"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": file(period),
+			"sys/fs/cgroup/cpu,cpuacct/cpu.stat":          file(""),
+			"sys/fs/cgroup/cpuset/cpuset.cpus":            file("0-3\n"),
+		})
+	}
+	v2 := func(max string) *Reader {
+		return New(fstest.MapFS{
+			"sys/fs/cgroup/cgroup.controllers":    file("cpuset cpu\n"),
+			"sys/fs/cgroup/cpu.stat":              file("usage_usec 0\n"),
+			"sys/fs/cgroup/cpu.max":               file(max),
+			"sys/fs/cgroup/cpuset.cpus.effective": file("0-3\n"),
+		})
+	}
+	tests := []struct {
+		name  string
+		r     *Reader
+		limit float64
+		err   bool
+	}{
+		{"v1", v1("20000\n", "100000\n"), 0.2, false},
+		{"v1 no quota", v1("-1\n", "100000\n"), 0, false},
+		{"v1 zero period", v1("20000\n", "0\n"), 0, true},
+		{"v2", v2("20000 100000\n"), 0.2, false},
+		{"v2 no quota", v2("max 100000\n"), 0, false},
+		{"v2 zero period", v2("20000 0\n"), 0, true},
+		{"v2 invalid", v2("20000\n"), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpu, err := tt.r.CPU()
+			if tt.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.limit, cpu.Limit)
+			assert.Equal(t, []uint64{0, 1, 2, 3}, cpu.CPUs)
+		})
+	}
+}
@@ -3,16 +3,17 @@ package window
 import (
 	"fmt"
 	"time"
+
+	"github.com/go-kratos/aegis/clock"
 )
 
 // Metric is a sample interface.
-// Implementations of Metrics in metric package are Counter, Gauge,
-// PointGauge, RollingCounter and RollingGauge.
+// Implementations of Metric in window package are RollingCounter and RollingGauge.
 type Metric interface {
 	// Add adds the given value to the counter.
 	Add(int64)
 	// Value gets the current value.
-	// If the metric's type is PointGauge, RollingCounter, RollingGauge,
+	// If the metric's type is RollingCounter or RollingGauge,
 	// it returns the sum value within the window.
 	Value() int64
 }
@@ -36,6 +37,7 @@ type RollingCounter interface {
 	Metric
 	Aggregation
 
+	// Timespan returns passed bucket number since the last value is added.
 	Timespan() int
 	// Reduce applies the reduction function to all buckets within the window.
 	Reduce(func(Iterator) float64) float64
@@ -45,6 +47,8 @@ type RollingCounter interface {
 type RollingCounterOpts struct {
 	Size           int
 	BucketDuration time.Duration
+	// Clock is the time source, default is the wall clock.
+	Clock clock.Clock
 }
 
 type rollingCounter struct {
@@ -54,7 +58,7 @@ type rollingCounter struct {
 // NewRollingCounter creates a new RollingCounter bases on RollingCounterOpts.
 func NewRollingCounter(opts RollingCounterOpts) RollingCounter {
 	window := NewWindow(Options{Size: opts.Size})
-	policy := NewRollingPolicy(window, RollingPolicyOpts{BucketDuration: opts.BucketDuration})
+	policy := NewRollingPolicy(window, RollingPolicyOpts{BucketDuration: opts.BucketDuration, Clock: opts.Clock})
 	return &rollingCounter{
 		policy: policy,
 	}
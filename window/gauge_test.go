@@ -0,0 +1,30 @@
+package window
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollingGauge(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	r := NewRollingGauge(RollingGaugeOpts{Size: 3, BucketDuration: time.Second, Clock: c})
+	r.Add(1)
+	r.Add(-2)
+	c.Advance(time.Second)
+	r.Add(3)
+	r.Add(6)
+	assert.Equal(t, float64(8), r.Sum())
+	assert.Equal(t, int64(8), r.Value())
+	assert.Equal(t, float64(2), r.Avg())
+	assert.Equal(t, float64(-2), r.Min())
+	assert.Equal(t, float64(6), r.Max())
+	assert.Equal(t, float64(4), r.Reduce(Count))
+
+	c.Advance(2 * time.Second)
+	assert.Equal(t, float64(9), r.Sum())
+	c.Advance(time.Second)
+	assert.Equal(t, float64(0), r.Sum())
+}
@@ -0,0 +1,135 @@
+package window
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+)
+
+// RollingPercentile represents a ring window based on time duration,
+// every bucket is a histogram of the values added, so the memory
+// is bounded regardless of the number of values.
+// e.g. bounds [10, 20] => [[1, 0, 0], [3, 2, 0], [0, 1, 5]]
+type RollingPercentile interface {
+	// Add records the given value.
+	Add(int64)
+	// Count returns the number of values within the window.
+	Count() int64
+	// Percentile returns the p-th percentile in range [0, 1] of the values within the window,
+	// it is interpolated linearly within the histogram bucket.
+	Percentile(p float64) float64
+	// Reduce applies the reduction function to all buckets within the window,
+	// the points of each bucket are the counts of the histogram buckets.
+	Reduce(func(Iterator) float64) float64
+}
+
+// RollingPercentileOpts contains the arguments for creating RollingPercentile.
+type RollingPercentileOpts struct {
+	Size           int
+	BucketDuration time.Duration
+	// Bounds are the sorted upper bounds of the histogram buckets,
+	// values greater than the last bound are counted into an overflow bucket.
+	Bounds []float64
+	// Clock is the time source, default is the wall clock.
+	Clock clock.Clock
+}
+
+type rollingPercentile struct {
+	policy *RollingPolicy
+	bounds []float64
+}
+
+// NewRollingPercentile creates a new RollingPercentile bases on RollingPercentileOpts.
+func NewRollingPercentile(opts RollingPercentileOpts) RollingPercentile {
+	window := NewWindow(Options{Size: opts.Size})
+	policy := NewRollingPolicy(window, RollingPolicyOpts{BucketDuration: opts.BucketDuration, Clock: opts.Clock})
+	return &rollingPercentile{
+		policy: policy,
+		bounds: opts.Bounds,
+	}
+}
+
+// ExponentialBounds returns count bounds, where the first bound is start
+// and each following bound is factor times the previous one.
+func ExponentialBounds(start, factor float64, count int) []float64 {
+	bounds := make([]float64, count)
+	for i := range bounds {
+		bounds[i] = start
+		start *= factor
+	}
+	return bounds
+}
+
+// LinearBounds returns count bounds, where the first bound is start
+// and each following bound is width greater than the previous one.
+func LinearBounds(start, width float64, count int) []float64 {
+	bounds := make([]float64, count)
+	for i := range bounds {
+		bounds[i] = start
+		start += width
+	}
+	return bounds
+}
+
+func (r *rollingPercentile) Add(val int64) {
+	index := sort.SearchFloat64s(r.bounds, float64(val))
+	r.policy.apply(func(offset int, _ float64) {
+		bucket := &r.policy.window.buckets[offset%r.policy.size]
+		if len(bucket.Points) == 0 {
+			// reuse the points of the reset bucket
+			for i := 0; i <= len(r.bounds); i++ {
+				bucket.Points = append(bucket.Points, 0)
+			}
+		}
+		bucket.Add(index, 1)
+	}, 0)
+}
+
+func (r *rollingPercentile) Reduce(f func(Iterator) float64) float64 {
+	return r.policy.Reduce(f)
+}
+
+func (r *rollingPercentile) Count() int64 {
+	return int64(r.policy.Reduce(Count))
+}
+
+func (r *rollingPercentile) Percentile(p float64) float64 {
+	counts := make([]float64, len(r.bounds)+1)
+	total := r.policy.Reduce(func(iterator Iterator) float64 {
+		var total float64
+		for iterator.Next() {
+			bucket := iterator.Bucket()
+			for i, c := range bucket.Points {
+				counts[i] += c
+			}
+			total += float64(bucket.Count)
+		}
+		return total
+	})
+	if total == 0 {
+		return 0
+	}
+	rank := math.Max(p, 0) * total
+	var seen float64
+	for i, c := range counts {
+		if c == 0 || seen+c < rank {
+			seen += c
+			continue
+		}
+		if i == len(r.bounds) {
+			// the overflow bucket has no upper bound
+			break
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = r.bounds[i-1]
+		}
+		return lower + (r.bounds[i]-lower)*(rank-seen)/c
+	}
+	if len(r.bounds) == 0 {
+		return 0
+	}
+	return r.bounds[len(r.bounds)-1]
+}
@@ -0,0 +1,50 @@
+package window
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBounds(t *testing.T) {
+	assert.Equal(t, []float64{1, 2, 4, 8}, ExponentialBounds(1, 2, 4))
+	assert.Equal(t, []float64{10, 15, 20}, LinearBounds(10, 5, 3))
+}
+
+func TestRollingPercentile(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	r := NewRollingPercentile(RollingPercentileOpts{
+		Size:           3,
+		BucketDuration: time.Second,
+		Bounds:         LinearBounds(10, 10, 10),
+		Clock:          c,
+	})
+	assert.Equal(t, float64(0), r.Percentile(0.99))
+	for i := 1; i <= 50; i++ {
+		r.Add(int64(i))
+	}
+	c.Advance(time.Second)
+	for i := 51; i <= 100; i++ {
+		r.Add(int64(i))
+	}
+	assert.Equal(t, int64(100), r.Count())
+	assert.Equal(t, float64(50), r.Percentile(0.5))
+	assert.Equal(t, float64(99), r.Percentile(0.99))
+	assert.Equal(t, float64(100), r.Percentile(1))
+	assert.Equal(t, float64(0), r.Percentile(0))
+
+	// overflow values are reported as the last bound
+	r.Add(1000)
+	assert.Equal(t, float64(100), r.Percentile(1))
+
+	// expired buckets are reset and reused
+	c.Advance(2 * time.Second)
+	assert.Equal(t, int64(51), r.Count())
+	assert.Equal(t, 75.5, r.Percentile(0.5))
+	c.Advance(time.Second)
+	r.Add(5)
+	assert.Equal(t, int64(1), r.Count())
+	assert.Equal(t, float64(10), r.Percentile(1))
+}
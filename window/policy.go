@@ -3,6 +3,8 @@ package window
 import (
 	"sync"
 	"time"
+
+	"github.com/go-kratos/aegis/clock"
 )
 
 // RollingPolicy is a policy for ring window based on time duration.
@@ -17,22 +19,27 @@ type RollingPolicy struct {
 
 	bucketDuration time.Duration
 	lastAppendTime time.Time
+	clock          clock.Clock
 }
 
 // RollingPolicyOpts contains the arguments for creating RollingPolicy.
 type RollingPolicyOpts struct {
 	BucketDuration time.Duration
+	// Clock is the time source, default is the wall clock.
+	Clock clock.Clock
 }
 
 // NewRollingPolicy creates a new RollingPolicy based on the given window and RollingPolicyOpts.
 func NewRollingPolicy(window *Window, opts RollingPolicyOpts) *RollingPolicy {
+	c := clock.OrNew(opts.Clock)
 	return &RollingPolicy{
 		window: window,
 		size:   window.Size(),
 		offset: 0,
 
 		bucketDuration: opts.BucketDuration,
-		lastAppendTime: time.Now(),
+		lastAppendTime: c.Now(),
+		clock:          c,
 	}
 }
 
@@ -40,7 +47,7 @@ func NewRollingPolicy(window *Window, opts RollingPolicyOpts) *RollingPolicy {
 // if it is one bucket duration earlier than the last recorded
 // time, it will return the size.
 func (r *RollingPolicy) timespan() int {
-	v := int(time.Since(r.lastAppendTime) / r.bucketDuration)
+	v := int(r.clock.Now().Sub(r.lastAppendTime) / r.bucketDuration)
 	if v > -1 { // maybe time backwards
 		return v
 	}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-kratos/aegis/clock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -104,3 +105,30 @@ func TestRollingPolicy_AddWithTimespan(t *testing.T) {
 		assert.Equal(t, 0, len(policy.window.buckets[2].Points))
 	})
 }
+
+func TestRollingPolicy_AddWithClock(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	w := NewWindow(Options{Size: 3})
+	policy := NewRollingPolicy(w, RollingPolicyOpts{BucketDuration: 100 * time.Millisecond, Clock: c})
+
+	// bucket 0
+	policy.Add(0)
+	// bucket 1
+	c.Advance(100 * time.Millisecond)
+	policy.Add(1)
+	// bucket 2
+	c.Advance(100 * time.Millisecond)
+	policy.Add(2)
+	assert.Equal(t, float64(3), policy.Reduce(Sum))
+	// bucket 1
+	c.Advance(500 * time.Millisecond)
+	policy.Add(4)
+
+	assert.Equal(t, 0, len(policy.window.buckets[0].Points))
+	assert.Equal(t, 4, int(policy.window.buckets[1].Points[0]))
+	assert.Equal(t, 0, len(policy.window.buckets[2].Points))
+
+	// all buckets are expired
+	c.Advance(time.Hour)
+	assert.Equal(t, float64(0), policy.Reduce(Sum))
+}
@@ -1,3 +1,5 @@
+// Package window provides metrics based on a ring window of buckets,
+// where the buckets are rolled by time duration.
 package window
 
 // Bucket contains multiple float64 points.